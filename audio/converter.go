@@ -8,9 +8,11 @@ import (
 	"strings"
 )
 
-// Converter handles audio format conversion from MIDI files.
+// Converter handles audio format conversion from MIDI files. MIDI is first
+// rendered to WAV by a Renderer, then encoded with ffmpeg for compressed formats.
 type Converter struct {
 	ffmpegPath string
+	renderer   Renderer
 }
 
 // NewConverter creates a new audio converter.
@@ -24,61 +26,82 @@ func NewConverter(ffmpegPath string) *Converter {
 	}
 }
 
+// SetRenderer sets the backend used to render MIDI to WAV. If none is set,
+// the first available default renderer is used.
+func (c *Converter) SetRenderer(renderer Renderer) {
+	c.renderer = renderer
+}
+
 // Convert converts a MIDI file to the specified audio format.
 // Supported formats: mp3, wav, ogg, flac, aac, m4a
 func (c *Converter) Convert(midiPath, outputPath string, format string) error {
-	if !c.isFormatSupported(format) {
-		return fmt.Errorf("unsupported format: %s (supported: mp3, wav, ogg, flac, aac, m4a)", format)
+	renderer := c.renderer
+	if renderer == nil {
+		var err error
+		if renderer, err = DetectRenderer(DefaultRenderers("")...); err != nil {
+			return err
+		}
 	}
+	return c.convert(renderer, midiPath, outputPath, format)
+}
 
-	// Check if ffmpeg is available
-	if err := c.checkFFmpeg(); err != nil {
-		return fmt.Errorf("ffmpeg not found: %w. Please install ffmpeg to convert to audio formats", err)
+// ConvertWithSoundfont converts MIDI to audio using a specific soundfont,
+// rendered by the first available backend that can use it.
+func (c *Converter) ConvertWithSoundfont(midiPath, outputPath, soundfontPath, format string) error {
+	renderer, err := DetectRenderer(DefaultRenderers(soundfontPath)...)
+	if err != nil {
+		return err
 	}
+	return c.convert(renderer, midiPath, outputPath, format)
+}
 
+// convert renders midiPath with renderer and encodes the result as format.
+func (c *Converter) convert(renderer Renderer, midiPath, outputPath, format string) error {
 	// Determine output format from extension if not specified
 	if format == "" {
 		ext := strings.ToLower(filepath.Ext(outputPath))
 		format = strings.TrimPrefix(ext, ".")
 	}
 
-	// Build ffmpeg command
-	// For MIDI files, we need to use a soundfont or synthesizer
-	// Using fluidsynth via ffmpeg is the most reliable approach
-	args := []string{
-		"-i", midiPath,
-		"-acodec", c.getCodec(format),
-		"-ar", "44100",
-		"-ac", "2",
-		"-y", // Overwrite output file
-		outputPath,
+	if !c.isFormatSupported(format) {
+		return fmt.Errorf("unsupported format: %s (supported: mp3, wav, ogg, flac, aac, m4a)", format)
 	}
 
-	cmd := exec.Command(c.ffmpegPath, args...)
-	cmd.Stderr = os.Stderr
-	cmd.Stdout = os.Stdout
+	if !NeedsEncoder(format) {
+		if err := renderer.RenderFile(midiPath, outputPath); err != nil {
+			return fmt.Errorf("failed to render MIDI with %s: %w", renderer.Name(), err)
+		}
+		return nil
+	}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to convert MIDI to %s: %w", format, err)
+	// Check if ffmpeg is available
+	if err := c.checkFFmpeg(); err != nil {
+		return fmt.Errorf("%w: install ffmpeg to encode %s", ErrFFmpegNotFound, format)
 	}
 
-	return nil
-}
+	wavFile, err := os.CreateTemp("", "git2midi-*.wav")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary WAV file: %w", err)
+	}
+	wavPath := wavFile.Name()
+	wavFile.Close()
+	defer os.Remove(wavPath)
 
-// ConvertWithSoundfont converts MIDI to audio using a specific soundfont.
-func (c *Converter) ConvertWithSoundfont(midiPath, outputPath, soundfontPath, format string) error {
-	if err := c.checkFFmpeg(); err != nil {
-		return fmt.Errorf("ffmpeg not found: %w", err)
+	if err := renderer.RenderFile(midiPath, wavPath); err != nil {
+		return fmt.Errorf("failed to render MIDI with %s: %w", renderer.Name(), err)
 	}
 
-	// Use fluidsynth to convert MIDI with soundfont, then pipe to ffmpeg
-	// This requires both fluidsynth and ffmpeg
+	return c.encode(wavPath, outputPath, format)
+}
+
+// encode converts a WAV file to the specified format with ffmpeg.
+func (c *Converter) encode(wavPath, outputPath, format string) error {
 	args := []string{
-		"-i", midiPath,
+		"-i", wavPath,
 		"-acodec", c.getCodec(format),
 		"-ar", "44100",
 		"-ac", "2",
-		"-y",
+		"-y", // Overwrite output file
 		outputPath,
 	}
 
@@ -86,10 +109,18 @@ func (c *Converter) ConvertWithSoundfont(midiPath, outputPath, soundfontPath, fo
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = os.Stdout
 
-	return cmd.Run()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+	return nil
+}
+
+// NeedsEncoder reports whether the format requires ffmpeg after rendering.
+func NeedsEncoder(format string) bool {
+	return strings.ToLower(format) != "wav"
 }
 
-// IsAvailable checks if audio conversion is available (ffmpeg installed).
+// IsAvailable checks if the ffmpeg encoder stage is available.
 func (c *Converter) IsAvailable() bool {
 	return c.checkFFmpeg() == nil
 }
@@ -5,5 +5,10 @@ import "errors"
 var (
 	// ErrFFmpegNotFound is returned when ffmpeg is not found in PATH.
 	ErrFFmpegNotFound = errors.New("ffmpeg not found in PATH")
-)
 
+	// ErrInvalidSoundFont is returned when a SoundFont file cannot be parsed.
+	ErrInvalidSoundFont = errors.New("invalid soundfont")
+
+	// ErrNoRenderer is returned when no MIDI renderer backend is available.
+	ErrNoRenderer = errors.New("no MIDI renderer available")
+)
@@ -0,0 +1,196 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Renderer renders a MIDI file to a 16-bit PCM WAV file.
+type Renderer interface {
+	// Name returns the backend name used to select it, e.g. "fluidsynth".
+	Name() string
+	// Available returns nil if the backend can render, or the reason it cannot.
+	Available() error
+	// RenderFile renders the MIDI file at midiPath to a WAV file at wavPath.
+	RenderFile(midiPath, wavPath string) error
+}
+
+// systemSoundfonts lists common locations of General MIDI SoundFonts.
+var systemSoundfonts = []string{
+	"/usr/share/sounds/sf2/FluidR3_GM.sf2",
+	"/usr/share/soundfonts/FluidR3_GM.sf2",
+	"/usr/share/soundfonts/default.sf2",
+	"/usr/share/sounds/sf2/default-GM.sf2",
+	"/usr/local/share/soundfonts/default.sf2",
+	"/opt/homebrew/share/soundfonts/default.sf2",
+}
+
+// FindSoundfont returns the first General MIDI SoundFont found in a common
+// system location, or "" if there is none.
+func FindSoundfont() string {
+	for _, path := range systemSoundfonts {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// DefaultRenderers returns every backend in order of preference: fluidsynth,
+// timidity, the built-in SoundFont player and the built-in synthesizer.
+// soundfont may be empty, in which case a system SoundFont is used if found.
+func DefaultRenderers(soundfont string) []Renderer {
+	if soundfont == "" {
+		soundfont = FindSoundfont()
+	}
+	return []Renderer{
+		NewFluidSynth(soundfont),
+		NewTimidity(soundfont),
+		NewSoundFontFileRenderer(soundfont),
+		NewSynth(),
+	}
+}
+
+// RendererNames returns the names of all renderer backends.
+func RendererNames() []string {
+	names := make([]string, 0)
+	for _, r := range DefaultRenderers("") {
+		names = append(names, r.Name())
+	}
+	return names
+}
+
+// DetectRenderer returns the first available renderer among candidates. If
+// none is available the error lists why each one was rejected.
+func DetectRenderer(candidates ...Renderer) (Renderer, error) {
+	reasons := make([]string, 0, len(candidates))
+	for _, r := range candidates {
+		err := r.Available()
+		if err == nil {
+			return r, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", r.Name(), err))
+	}
+	return nil, fmt.Errorf("%w (%s)", ErrNoRenderer, strings.Join(reasons, "; "))
+}
+
+// SelectRenderer returns the named renderer, or the first available default
+// renderer if name is "auto" or empty.
+func SelectRenderer(name, soundfont string) (Renderer, error) {
+	candidates := DefaultRenderers(soundfont)
+	if name == "" || name == "auto" {
+		return DetectRenderer(candidates...)
+	}
+
+	for _, r := range candidates {
+		if r.Name() == name {
+			return DetectRenderer(r)
+		}
+	}
+	return nil, fmt.Errorf("unknown renderer: %s (supported: auto, %s)", name, strings.Join(RendererNames(), ", "))
+}
+
+// FluidSynth renders MIDI with the fluidsynth command-line synthesizer.
+type FluidSynth struct {
+	Path       string
+	Soundfont  string
+	SampleRate int
+}
+
+// NewFluidSynth creates a fluidsynth backend using the given SoundFont.
+func NewFluidSynth(soundfont string) *FluidSynth {
+	return &FluidSynth{
+		Path:       "fluidsynth",
+		Soundfont:  soundfont,
+		SampleRate: DefaultSampleRate,
+	}
+}
+
+// Name returns "fluidsynth".
+func (f *FluidSynth) Name() string {
+	return "fluidsynth"
+}
+
+// Available reports whether fluidsynth is on PATH and a SoundFont is configured.
+func (f *FluidSynth) Available() error {
+	if _, err := exec.LookPath(f.Path); err != nil {
+		return errors.New("executable not found in PATH")
+	}
+	if f.Soundfont == "" {
+		return errors.New("no soundfont configured")
+	}
+	return nil
+}
+
+// RenderFile renders midiPath to wavPath with fluidsynth.
+func (f *FluidSynth) RenderFile(midiPath, wavPath string) error {
+	args := []string{
+		"-ni",
+		"-T", "wav",
+		"-F", wavPath,
+		"-r", strconv.Itoa(f.SampleRate),
+		f.Soundfont,
+		midiPath,
+	}
+	return runRenderer(f.Name(), exec.Command(f.Path, args...), wavPath)
+}
+
+// Timidity renders MIDI with the TiMidity++ command-line player.
+type Timidity struct {
+	Path       string
+	Soundfont  string
+	SampleRate int
+}
+
+// NewTimidity creates a timidity backend. If soundfont is empty, timidity's
+// own configuration chooses the instruments.
+func NewTimidity(soundfont string) *Timidity {
+	return &Timidity{
+		Path:       "timidity",
+		Soundfont:  soundfont,
+		SampleRate: DefaultSampleRate,
+	}
+}
+
+// Name returns "timidity".
+func (t *Timidity) Name() string {
+	return "timidity"
+}
+
+// Available reports whether timidity is on PATH.
+func (t *Timidity) Available() error {
+	if _, err := exec.LookPath(t.Path); err != nil {
+		return errors.New("executable not found in PATH")
+	}
+	return nil
+}
+
+// RenderFile renders midiPath to wavPath with timidity.
+func (t *Timidity) RenderFile(midiPath, wavPath string) error {
+	args := []string{
+		"-Ow",
+		"-o", wavPath,
+		"-s", strconv.Itoa(t.SampleRate),
+	}
+	if t.Soundfont != "" {
+		args = append(args, "-x", "soundfont "+t.Soundfont)
+	}
+	args = append(args, midiPath)
+	return runRenderer(t.Name(), exec.Command(t.Path, args...), wavPath)
+}
+
+// runRenderer runs an external renderer and checks that it produced output.
+func runRenderer(name string, cmd *exec.Cmd, wavPath string) error {
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	if info, err := os.Stat(wavPath); err != nil || info.Size() == 0 {
+		return fmt.Errorf("%s produced no output", name)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package audio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeTool writes an executable shell script named name into dir. The script
+// records its arguments in name.args and writes a stub WAV file to the path
+// following outputFlag.
+func fakeTool(t *testing.T, dir, name, outputFlag string) {
+	t.Helper()
+	script := `#!/bin/sh
+echo "$@" > "` + filepath.Join(dir, name+".args") + `"
+while [ $# -gt 0 ]; do
+	if [ "$1" = "` + outputFlag + `" ]; then
+		printf 'RIFF' > "$2"
+	fi
+	shift
+done
+`
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake %s: %v", name, err)
+	}
+}
+
+func requireShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake executables require a POSIX shell")
+	}
+}
+
+func TestDetectRendererOrder(t *testing.T) {
+	requireShell(t)
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	soundfont := filepath.Join(dir, "font.sf2")
+	if err := os.WriteFile(soundfont, buildSoundFont(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	renderer, err := DetectRenderer(DefaultRenderers(soundfont)...)
+	if err != nil || renderer.Name() != "soundfont" {
+		t.Fatalf("without external tools: got %v, %v; want soundfont", renderer, err)
+	}
+
+	fakeTool(t, dir, "timidity", "-o")
+	renderer, err = DetectRenderer(DefaultRenderers(soundfont)...)
+	if err != nil || renderer.Name() != "timidity" {
+		t.Fatalf("with timidity: got %v, %v; want timidity", renderer, err)
+	}
+
+	fakeTool(t, dir, "fluidsynth", "-F")
+	renderer, err = DetectRenderer(DefaultRenderers(soundfont)...)
+	if err != nil || renderer.Name() != "fluidsynth" {
+		t.Fatalf("with fluidsynth: got %v, %v; want fluidsynth", renderer, err)
+	}
+}
+
+func TestExternalRenderers(t *testing.T) {
+	requireShell(t)
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	fakeTool(t, dir, "fluidsynth", "-F")
+	fakeTool(t, dir, "timidity", "-o")
+
+	tests := []struct {
+		renderer Renderer
+		args     []string
+	}{
+		{renderer: NewFluidSynth("gm.sf2"), args: []string{"-F", "gm.sf2", "in.mid"}},
+		{renderer: NewTimidity("gm.sf2"), args: []string{"-Ow", "soundfont gm.sf2", "in.mid"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.renderer.Name(), func(t *testing.T) {
+			wavPath := filepath.Join(dir, tt.renderer.Name()+".wav")
+			if err := tt.renderer.RenderFile("in.mid", wavPath); err != nil {
+				t.Fatalf("RenderFile failed: %v", err)
+			}
+			if _, err := os.Stat(wavPath); err != nil {
+				t.Errorf("output not written: %v", err)
+			}
+
+			args, err := os.ReadFile(filepath.Join(dir, tt.renderer.Name()+".args"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, arg := range tt.args {
+				if !strings.Contains(string(args), arg) {
+					t.Errorf("arguments %q missing %q", strings.TrimSpace(string(args)), arg)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectRendererUnavailable(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := SelectRenderer("fluidsynth", "")
+	if !errors.Is(err, ErrNoRenderer) {
+		t.Fatalf("error: got %v, want %v", err, ErrNoRenderer)
+	}
+	if !strings.Contains(err.Error(), "fluidsynth") {
+		t.Errorf("error %q does not name the backend", err)
+	}
+
+	_, err = DetectRenderer(NewTimidity(""), NewSoundFontFileRenderer(""))
+	if !errors.Is(err, ErrNoRenderer) {
+		t.Fatalf("error: got %v, want %v", err, ErrNoRenderer)
+	}
+
+	if _, err := SelectRenderer("nonexistent", ""); err == nil {
+		t.Errorf("expected error for unknown renderer")
+	}
+}
+
+func TestConvertEncodesWithFFmpeg(t *testing.T) {
+	requireShell(t)
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	fakeTool(t, dir, "fluidsynth", "-F")
+	fakeTool(t, dir, "ffmpeg", "-y")
+
+	converter := NewConverter("")
+	converter.SetRenderer(NewFluidSynth("gm.sf2"))
+
+	output := filepath.Join(dir, "out.mp3")
+	if err := converter.Convert("in.mid", output, "mp3"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "ffmpeg.args"))
+	if err != nil {
+		t.Fatalf("ffmpeg was not run: %v", err)
+	}
+	if !strings.Contains(string(args), "libmp3lame") || !strings.Contains(string(args), ".wav") {
+		t.Errorf("ffmpeg arguments %q do not encode the rendered WAV to mp3", strings.TrimSpace(string(args)))
+	}
+}
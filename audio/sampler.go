@@ -0,0 +1,177 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// percussionBank is the SoundFont bank that holds General MIDI drum kits.
+const percussionBank = 128
+
+// SoundFontRenderer renders MIDI to audio by playing back SoundFont samples.
+type SoundFontRenderer struct {
+	Font *SoundFont
+	// Path is the .sf2 file to load on first use if Font is nil.
+	Path       string
+	SampleRate int
+	// Gain is the amplitude of a full-velocity, unattenuated sample before mixing.
+	Gain float64
+}
+
+// NewSoundFontRenderer creates a sample-based renderer for font.
+func NewSoundFontRenderer(font *SoundFont) *SoundFontRenderer {
+	return &SoundFontRenderer{
+		Font:       font,
+		SampleRate: DefaultSampleRate,
+		Gain:       0.5,
+	}
+}
+
+// NewSoundFontFileRenderer creates a sample-based renderer that loads the
+// SoundFont at path when it first renders.
+func NewSoundFontFileRenderer(path string) *SoundFontRenderer {
+	r := NewSoundFontRenderer(nil)
+	r.Path = path
+	return r
+}
+
+// Render plays the MIDI file held by w through the SoundFont into a stereo
+// buffer. Program Change and bank select (CC 0) choose presets; channel 10
+// uses the percussion bank.
+func (r *SoundFontRenderer) Render(w *midi.Writer) (*Buffer, error) {
+	if r.Font == nil && r.Path != "" {
+		font, err := LoadSoundFont(r.Path)
+		if err != nil {
+			return nil, err
+		}
+		r.Font = font
+	}
+	if r.Font == nil || len(r.Font.Presets) == 0 {
+		return nil, fmt.Errorf("%w: no presets", ErrInvalidSoundFont)
+	}
+	if r.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate: %d", r.SampleRate)
+	}
+
+	voices, length := sequence(w)
+
+	type playback struct {
+		voice  voice
+		region *Region
+	}
+	plays := make([]playback, 0, len(voices))
+	tail := 0.0
+	for _, v := range voices {
+		bank := int(v.bank)
+		if v.channel == DrumChannel {
+			bank = percussionBank
+		}
+		preset := r.Font.Preset(bank, int(v.program))
+		for _, region := range preset.Match(v.key, v.velocity) {
+			plays = append(plays, playback{voice: v, region: region})
+			if end := v.end + region.Envelope.Release; end > length+tail {
+				tail = end - length
+			}
+		}
+	}
+
+	buf := newBuffer(r.SampleRate, length+tail)
+	for _, p := range plays {
+		r.renderRegion(buf, p.voice, p.region)
+	}
+	buf.Normalize(0.99)
+
+	return buf, nil
+}
+
+// Name returns "soundfont".
+func (r *SoundFontRenderer) Name() string {
+	return "soundfont"
+}
+
+// Available reports whether a SoundFont is loaded or its file exists.
+func (r *SoundFontRenderer) Available() error {
+	if r.Font != nil {
+		return nil
+	}
+	if r.Path == "" {
+		return errors.New("no soundfont configured")
+	}
+	if _, err := os.Stat(r.Path); err != nil {
+		return fmt.Errorf("soundfont not readable: %w", err)
+	}
+	return nil
+}
+
+// RenderFile renders the MIDI file at midiPath to a WAV file at wavPath.
+func (r *SoundFontRenderer) RenderFile(midiPath, wavPath string) error {
+	w, err := midi.ReadFile(midiPath)
+	if err != nil {
+		return fmt.Errorf("failed to read MIDI file: %w", err)
+	}
+
+	buf, err := r.Render(w)
+	if err != nil {
+		return err
+	}
+	return buf.WriteWAV(wavPath)
+}
+
+// renderRegion mixes one region of a note into buf, resampling the region's
+// sample with linear interpolation.
+func (r *SoundFontRenderer) renderRegion(buf *Buffer, v voice, region *Region) {
+	data := r.Font.data
+	if region.End > uint32(len(data)) || region.Start+1 >= region.End {
+		return
+	}
+
+	rate := float64(r.SampleRate)
+	cents := float64(int(v.key)-region.RootKey)*region.ScaleTuning + v.bend*100 + region.Tune
+	step := math.Pow(2, cents/1200) * float64(region.Sample.SampleRate) / rate
+
+	amplitude := r.Gain * v.gain * centibels(region.Attenuation) * float64(v.velocity) * float64(v.velocity) / (127 * 127)
+	pan := math.Max(-1, math.Min(1, region.Pan+v.pan))
+	left := amplitude * math.Cos((pan+1)*math.Pi/4)
+	right := amplitude * math.Sin((pan+1)*math.Pi/4)
+
+	env := region.Envelope
+	gate := v.end - v.start
+	first := int(v.start * rate)
+	loopLength := float64(region.LoopEnd - region.LoopStart)
+	pos := float64(region.Start)
+
+	for i := first; i < buf.Frames(); i++ {
+		t := float64(i-first) / rate
+		level := env.level(t, gate)
+		if level <= 0 && t >= gate {
+			break
+		}
+
+		looping := region.LoopMode == LoopContinuous || (region.LoopMode == LoopUntilRelease && t < gate)
+		if looping {
+			for pos >= float64(region.LoopEnd) {
+				pos -= loopLength
+			}
+		} else if pos >= float64(region.End-1) {
+			break
+		}
+
+		index := int(pos)
+		frac := pos - float64(index)
+		next := index + 1
+		if looping && uint32(next) >= region.LoopEnd {
+			next = int(region.LoopStart)
+		}
+		sample := (float64(data[index]) + (float64(data[next])-float64(data[index]))*frac) / 32768
+
+		value := sample * level
+		buf.Samples[2*i] += float32(value * left)
+		buf.Samples[2*i+1] += float32(value * right)
+
+		pos += step
+	}
+}
@@ -0,0 +1,227 @@
+package audio
+
+import (
+	"sort"
+
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// DrumChannel is the General MIDI percussion channel (channel 10, zero-based 9).
+const DrumChannel = 9
+
+// voice is a note resolved to wall-clock time, together with the channel
+// state in effect when it started.
+type voice struct {
+	start    float64
+	end      float64
+	channel  byte
+	key      byte
+	velocity byte
+	program  byte
+	bank     byte
+	// gain is the channel volume and expression as a factor from 0 to 1.
+	gain float64
+	// pan is the stereo position from -1 (left) to 1 (right).
+	pan float64
+	// bend is the pitch bend in semitones.
+	bend float64
+}
+
+// pitchBendRange is the default pitch bend sensitivity in semitones.
+const pitchBendRange = 2
+
+// channelState is the controller state of a MIDI channel.
+type channelState struct {
+	program    byte
+	bank       byte
+	volume     byte
+	expression byte
+	pan        byte
+	bend       int
+	sustain    bool
+	// held lists voices released while the sustain pedal was down.
+	held []int
+}
+
+// newChannelState returns the General MIDI power-on state of a channel.
+func newChannelState() channelState {
+	return channelState{volume: 100, expression: 127, pan: 64}
+}
+
+// gain returns the channel volume and expression as a factor from 0 to 1.
+func (c *channelState) gain() float64 {
+	return float64(c.volume) / 127 * float64(c.expression) / 127
+}
+
+// panPosition returns the channel pan as a position from -1 to 1.
+func (c *channelState) panPosition() float64 {
+	return (float64(c.pan) - 64) / 63
+}
+
+// timedEvent is an event from any track at an absolute tick.
+type timedEvent struct {
+	tick  uint32
+	track int
+	data  []byte
+}
+
+// sequence merges all tracks of a MIDI file, applies the tempo map and pairs
+// Note On with Note Off events. It returns the notes in start order and the
+// total length of the piece in seconds.
+func sequence(w *midi.Writer) ([]voice, float64) {
+	events := make([]timedEvent, 0)
+	for i, track := range w.Tracks() {
+		tick := uint32(0)
+		for _, event := range track.Events() {
+			tick += event.DeltaTime
+			events = append(events, timedEvent{tick: tick, track: i, data: event.Data})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].tick < events[j].tick
+	})
+
+	clock := newTempoClock(w.GetDivision())
+	var channels [16]channelState
+	for i := range channels {
+		channels[i] = newChannelState()
+	}
+	active := make(map[[2]byte][]int)
+	voices := make([]voice, 0)
+
+	release := func(channel, key byte, at float64) {
+		id := [2]byte{channel, key}
+		started := active[id]
+		if len(started) == 0 {
+			return
+		}
+		active[id] = started[1:]
+		if channels[channel].sustain {
+			channels[channel].held = append(channels[channel].held, started[0])
+			return
+		}
+		voices[started[0]].end = at
+	}
+
+	for _, event := range events {
+		now := clock.seconds(event.tick)
+		data := event.data
+		if len(data) == 0 {
+			continue
+		}
+
+		switch status := data[0]; {
+		case status == 0xFF && len(data) >= 6 && data[1] == 0x51:
+			clock.setTempo(event.tick, uint32(data[3])<<16|uint32(data[4])<<8|uint32(data[5]))
+
+		case status&0xF0 == 0x90 && len(data) >= 3 && data[2] > 0:
+			channel, key := status&0x0F, data[1]
+			active[[2]byte{channel, key}] = append(active[[2]byte{channel, key}], len(voices))
+			voices = append(voices, voice{
+				start:    now,
+				end:      -1,
+				channel:  channel,
+				key:      key,
+				velocity: data[2],
+				program:  channels[channel].program,
+				bank:     channels[channel].bank,
+				gain:     channels[channel].gain(),
+				pan:      channels[channel].panPosition(),
+				bend:     float64(channels[channel].bend) / 8192 * pitchBendRange,
+			})
+
+		case (status&0xF0 == 0x80 || status&0xF0 == 0x90) && len(data) >= 3:
+			release(status&0x0F, data[1], now)
+
+		case status&0xF0 == 0xC0 && len(data) >= 2:
+			channels[status&0x0F].program = data[1]
+
+		case status&0xF0 == 0xB0 && len(data) >= 3:
+			state := &channels[status&0x0F]
+			switch data[1] {
+			case midi.CCBankSelect:
+				state.bank = data[2]
+			case midi.CCVolume:
+				state.volume = data[2]
+			case midi.CCExpression:
+				state.expression = data[2]
+			case midi.CCPan:
+				state.pan = data[2]
+			case midi.CCSustain:
+				state.sustain = data[2] >= 64
+				if !state.sustain {
+					for _, i := range state.held {
+						voices[i].end = now
+					}
+					state.held = nil
+				}
+			}
+
+		case status&0xF0 == 0xE0 && len(data) >= 3:
+			channels[status&0x0F].bend = (int(data[1]) | int(data[2])<<7) - 8192
+		}
+	}
+
+	length := 0.0
+	if len(events) > 0 {
+		length = clock.seconds(events[len(events)-1].tick)
+	}
+
+	// Notes never released ring until the end of the piece.
+	for i := range voices {
+		if voices[i].end < 0 {
+			voices[i].end = length
+		}
+		if voices[i].end > length {
+			length = voices[i].end
+		}
+	}
+
+	return voices, length
+}
+
+// tempoClock converts absolute ticks into seconds, following tempo changes.
+type tempoClock struct {
+	ticksPerQuarter float64
+	ticksPerSecond  float64
+	tempo           float64
+	lastTick        uint32
+	lastSeconds     float64
+}
+
+// newTempoClock creates a clock for the given header division. Divisions with
+// the high bit set are SMPTE-based and ignore tempo changes.
+func newTempoClock(division uint16) *tempoClock {
+	clock := &tempoClock{tempo: 500000}
+	if division&0x8000 != 0 {
+		framesPerSecond := float64(-int8(division >> 8))
+		if framesPerSecond == 29 {
+			framesPerSecond = 29.97
+		}
+		clock.ticksPerSecond = framesPerSecond * float64(division&0xFF)
+	} else {
+		clock.ticksPerQuarter = float64(division)
+		if clock.ticksPerQuarter == 0 {
+			clock.ticksPerQuarter = 480
+		}
+	}
+	return clock
+}
+
+// seconds returns the time of tick. Ticks must not decrease between calls.
+func (c *tempoClock) seconds(tick uint32) float64 {
+	elapsed := float64(tick - c.lastTick)
+	if c.ticksPerSecond > 0 {
+		return c.lastSeconds + elapsed/c.ticksPerSecond
+	}
+	return c.lastSeconds + elapsed*c.tempo/1e6/c.ticksPerQuarter
+}
+
+// setTempo applies a new tempo in microseconds per quarter note from tick on.
+func (c *tempoClock) setTempo(tick uint32, tempo uint32) {
+	c.lastSeconds = c.seconds(tick)
+	c.lastTick = tick
+	if tempo > 0 {
+		c.tempo = float64(tempo)
+	}
+}
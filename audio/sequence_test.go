@@ -0,0 +1,55 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/klejdi94/git2midi/midi"
+)
+
+func TestSequence(t *testing.T) {
+	writer := midi.NewWriter(1, 480)
+
+	// The conductor track doubles the tempo after the first beat.
+	conductor := midi.NewTrack()
+	conductor.AddTempo(0, 500000)
+	conductor.AddTempo(480, 250000)
+	conductor.AddEndOfTrack(0)
+	writer.AddTrack(conductor)
+
+	track := midi.NewTrack()
+	track.AddNoteOn(0, 0, 60, 100)
+	track.AddNoteOff(480, 0, 60, 64)
+	track.AddControlChange(0, 0, midi.CCSustain, 127)
+	track.AddPitchBend(0, 0, 4096)
+	track.AddNoteOn(0, 0, 62, 90)
+	// Released under the pedal, it sounds until the pedal lifts.
+	track.AddNoteOff(240, 0, 62, 64)
+	track.AddControlChange(240, 0, midi.CCSustain, 0)
+	track.AddNoteOn(0, 0, 64, 80)
+	track.AddNoteOff(480, 0, 64, 64)
+	track.AddEndOfTrack(0)
+	writer.AddTrack(track)
+
+	voices, length := sequence(writer)
+
+	want := []voice{
+		{start: 0, end: 0.5, key: 60, velocity: 100},
+		{start: 0.5, end: 0.75, key: 62, velocity: 90, bend: 1},
+		{start: 0.75, end: 1, key: 64, velocity: 80, bend: 1},
+	}
+	if len(voices) != len(want) {
+		t.Fatalf("voices: got %d, want %d", len(voices), len(want))
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for i, v := range voices {
+		w := want[i]
+		if v.key != w.key || v.velocity != w.velocity || !near(v.start, w.start) || !near(v.end, w.end) || !near(v.bend, w.bend) {
+			t.Errorf("voice %d: got key %d velocity %d %g-%gs bend %g, want key %d velocity %d %g-%gs bend %g",
+				i, v.key, v.velocity, v.start, v.end, v.bend, w.key, w.velocity, w.start, w.end, w.bend)
+		}
+	}
+	if !near(length, 1) {
+		t.Errorf("length: got %gs, want 1s", length)
+	}
+}
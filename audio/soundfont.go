@@ -0,0 +1,531 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// SoundFont is a parsed SoundFont 2 (.sf2) bank.
+type SoundFont struct {
+	Name    string
+	Presets []*Preset
+	Samples []*Sample
+
+	// data holds the 16-bit sample pool that Sample offsets refer to.
+	data []int16
+}
+
+// Preset is a playable SoundFont preset, selected by bank and program number.
+type Preset struct {
+	Name    string
+	Bank    int
+	Program int
+	Regions []*Region
+}
+
+// Sample describes a sample in the SoundFont's sample pool. Offsets are in
+// sample points from the start of the pool.
+type Sample struct {
+	Name            string
+	Start           uint32
+	End             uint32
+	LoopStart       uint32
+	LoopEnd         uint32
+	SampleRate      uint32
+	OriginalPitch   byte
+	PitchCorrection int8
+	Type            uint16
+}
+
+// LoopMode describes how a region loops its sample.
+type LoopMode int
+
+const (
+	// LoopNone plays the sample once.
+	LoopNone LoopMode = 0
+	// LoopContinuous loops the sample for as long as the note sounds.
+	LoopContinuous LoopMode = 1
+	// LoopUntilRelease loops while the key is held, then plays to the end.
+	LoopUntilRelease LoopMode = 3
+)
+
+// Region is a preset zone resolved against its instrument zone: everything
+// needed to play one sample for a range of keys and velocities.
+type Region struct {
+	KeyLow  byte
+	KeyHigh byte
+	VelLow  byte
+	VelHigh byte
+
+	Sample    *Sample
+	Start     uint32
+	End       uint32
+	LoopStart uint32
+	LoopEnd   uint32
+	LoopMode  LoopMode
+
+	// RootKey is the key at which the sample plays at its recorded pitch.
+	RootKey int
+	// Tune is the pitch offset in cents.
+	Tune float64
+	// ScaleTuning is the pitch change per key in cents (100 = equal temperament).
+	ScaleTuning float64
+	// Attenuation is the initial attenuation in centibels.
+	Attenuation float64
+	// Pan is the stereo position from -1 (left) to 1 (right).
+	Pan float64
+	// Envelope is the volume envelope.
+	Envelope Envelope
+}
+
+// SoundFont 2 generator operators used by the loader.
+const (
+	genStartAddrsOffset       = 0
+	genEndAddrsOffset         = 1
+	genStartloopAddrsOffset   = 2
+	genEndloopAddrsOffset     = 3
+	genStartAddrsCoarseOffset = 4
+	genEndAddrsCoarseOffset   = 12
+	genPan                    = 17
+	genDelayVolEnv            = 33
+	genAttackVolEnv           = 34
+	genHoldVolEnv             = 35
+	genDecayVolEnv            = 36
+	genSustainVolEnv          = 37
+	genReleaseVolEnv          = 38
+	genInstrument             = 41
+	genKeyRange               = 43
+	genVelRange               = 44
+	genStartloopCoarseOffset  = 45
+	genInitialAttenuation     = 48
+	genEndloopCoarseOffset    = 50
+	genCoarseTune             = 51
+	genFineTune               = 52
+	genSampleID               = 53
+	genSampleModes            = 54
+	genScaleTuning            = 56
+	genOverridingRootKey      = 58
+	genCount                  = 61
+)
+
+// generators holds the generator values of a zone, indexed by operator.
+type generators struct {
+	values [genCount]int16
+	set    [genCount]bool
+}
+
+func (g *generators) put(op uint16, amount int16) {
+	if int(op) < genCount {
+		g.values[op] = amount
+		g.set[op] = true
+	}
+}
+
+// byteRange returns the low and high byte of a range generator.
+func (g *generators) byteRange(op int) (byte, byte) {
+	if !g.set[op] {
+		return 0, 127
+	}
+	amount := uint16(g.values[op])
+	return byte(amount), byte(amount >> 8)
+}
+
+// zone is a raw preset or instrument zone: its generators plus the index of
+// the instrument or sample it refers to, or -1 for a global zone.
+type zone struct {
+	gens   generators
+	target int
+}
+
+// LoadSoundFont reads and parses the .sf2 file at path.
+func LoadSoundFont(path string) (*SoundFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read soundfont: %w", err)
+	}
+	return ParseSoundFont(data)
+}
+
+// ParseSoundFont parses SoundFont 2 data.
+func ParseSoundFont(data []byte) (*SoundFont, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "sfbk" {
+		return nil, fmt.Errorf("%w: missing RIFF sfbk header", ErrInvalidSoundFont)
+	}
+
+	riffSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	if riffSize < 4 {
+		return nil, fmt.Errorf("%w: RIFF size %d too small", ErrInvalidSoundFont, riffSize)
+	}
+	if riffSize+8 < len(data) {
+		data = data[:riffSize+8]
+	}
+
+	lists, err := readChunks(data[12:])
+	if err != nil {
+		return nil, err
+	}
+
+	font := &SoundFont{}
+	pdta := make(map[string][]byte)
+
+	for _, list := range lists {
+		if list.id != "LIST" || len(list.data) < 4 {
+			continue
+		}
+		children, err := readChunks(list.data[4:])
+		if err != nil {
+			return nil, err
+		}
+
+		switch string(list.data[0:4]) {
+		case "INFO":
+			for _, child := range children {
+				if child.id == "INAM" {
+					font.Name = cString(child.data)
+				}
+			}
+		case "sdta":
+			for _, child := range children {
+				if child.id == "smpl" {
+					font.data = make([]int16, len(child.data)/2)
+					for i := range font.data {
+						font.data[i] = int16(binary.LittleEndian.Uint16(child.data[2*i:]))
+					}
+				}
+			}
+		case "pdta":
+			for _, child := range children {
+				pdta[child.id] = child.data
+			}
+		}
+	}
+
+	if font.data == nil {
+		return nil, fmt.Errorf("%w: missing sample data", ErrInvalidSoundFont)
+	}
+	for _, id := range []string{"phdr", "pbag", "pgen", "inst", "ibag", "igen", "shdr"} {
+		if _, ok := pdta[id]; !ok {
+			return nil, fmt.Errorf("%w: missing %s chunk", ErrInvalidSoundFont, id)
+		}
+	}
+
+	if err := font.parseSamples(pdta["shdr"]); err != nil {
+		return nil, err
+	}
+
+	instruments, err := parseZones(pdta["inst"], 22, 20, pdta["ibag"], pdta["igen"], genSampleID)
+	if err != nil {
+		return nil, fmt.Errorf("instruments: %w", err)
+	}
+	presetZones, err := parseZones(pdta["phdr"], 38, 24, pdta["pbag"], pdta["pgen"], genInstrument)
+	if err != nil {
+		return nil, fmt.Errorf("presets: %w", err)
+	}
+
+	phdr := pdta["phdr"]
+	for i, zones := range presetZones {
+		header := phdr[i*38:]
+		preset := &Preset{
+			Name:    cString(header[0:20]),
+			Program: int(binary.LittleEndian.Uint16(header[20:22])),
+			Bank:    int(binary.LittleEndian.Uint16(header[22:24])),
+		}
+		preset.Regions = font.resolveRegions(zones, instruments)
+		font.Presets = append(font.Presets, preset)
+	}
+
+	return font, nil
+}
+
+// Preset returns the preset for bank and program, falling back to the same
+// program in bank 0 and then to the first preset. It returns nil only if the
+// SoundFont has no presets.
+func (sf *SoundFont) Preset(bank, program int) *Preset {
+	var fallback *Preset
+	for _, preset := range sf.Presets {
+		if preset.Program == program && preset.Bank == bank {
+			return preset
+		}
+		if preset.Program == program && preset.Bank == 0 && fallback == nil {
+			fallback = preset
+		}
+	}
+	if fallback != nil {
+		return fallback
+	}
+	if len(sf.Presets) > 0 {
+		return sf.Presets[0]
+	}
+	return nil
+}
+
+// Match returns the preset's regions that play key at velocity.
+func (p *Preset) Match(key, velocity byte) []*Region {
+	matched := make([]*Region, 0, 2)
+	for _, region := range p.Regions {
+		if key >= region.KeyLow && key <= region.KeyHigh &&
+			velocity >= region.VelLow && velocity <= region.VelHigh {
+			matched = append(matched, region)
+		}
+	}
+	return matched
+}
+
+func (sf *SoundFont) parseSamples(shdr []byte) error {
+	const size = 46
+	count := len(shdr)/size - 1 // the last record is the EOS terminator
+	for i := 0; i < count; i++ {
+		record := shdr[i*size:]
+		sample := &Sample{
+			Name:            cString(record[0:20]),
+			Start:           binary.LittleEndian.Uint32(record[20:24]),
+			End:             binary.LittleEndian.Uint32(record[24:28]),
+			LoopStart:       binary.LittleEndian.Uint32(record[28:32]),
+			LoopEnd:         binary.LittleEndian.Uint32(record[32:36]),
+			SampleRate:      binary.LittleEndian.Uint32(record[36:40]),
+			OriginalPitch:   record[40],
+			PitchCorrection: int8(record[41]),
+			Type:            binary.LittleEndian.Uint16(record[44:46]),
+		}
+		if sample.End > uint32(len(sf.data)) || sample.Start > sample.End {
+			return fmt.Errorf("%w: sample %q out of bounds", ErrInvalidSoundFont, sample.Name)
+		}
+		sf.Samples = append(sf.Samples, sample)
+	}
+	return nil
+}
+
+// parseZones decodes the zones of each preset or instrument header. Headers
+// are recordSize bytes long with their first bag index at bagOffset, and the
+// final header is a terminator. terminal is the generator that links a zone
+// to its instrument or sample.
+func parseZones(headers []byte, recordSize, bagOffset int, bags, gens []byte, terminal int) ([][]zone, error) {
+	count := len(headers)/recordSize - 1
+	if count < 0 {
+		return nil, fmt.Errorf("%w: empty header list", ErrInvalidSoundFont)
+	}
+
+	bagIndex := func(i int) int {
+		return int(binary.LittleEndian.Uint16(headers[i*recordSize+bagOffset:]))
+	}
+	genIndex := func(bag int) (int, error) {
+		if bag*4+2 > len(bags) {
+			return 0, fmt.Errorf("%w: bag index %d out of range", ErrInvalidSoundFont, bag)
+		}
+		return int(binary.LittleEndian.Uint16(bags[bag*4:])), nil
+	}
+
+	result := make([][]zone, count)
+	for i := 0; i < count; i++ {
+		first, last := bagIndex(i), bagIndex(i+1)
+		for bag := first; bag < last; bag++ {
+			start, err := genIndex(bag)
+			if err != nil {
+				return nil, err
+			}
+			end, err := genIndex(bag + 1)
+			if err != nil {
+				return nil, err
+			}
+			if end*4 > len(gens) || start > end {
+				return nil, fmt.Errorf("%w: generator index out of range", ErrInvalidSoundFont)
+			}
+
+			z := zone{target: -1}
+			for g := start; g < end; g++ {
+				op := binary.LittleEndian.Uint16(gens[g*4:])
+				amount := int16(binary.LittleEndian.Uint16(gens[g*4+2:]))
+				if int(op) == terminal {
+					z.target = int(uint16(amount))
+				} else {
+					z.gens.put(op, amount)
+				}
+			}
+			result[i] = append(result[i], z)
+		}
+	}
+	return result, nil
+}
+
+// resolveRegions combines the zones of a preset with the zones of the
+// instruments they reference. Instrument values are absolute, preset values
+// are added on top, and global zones provide defaults at each level.
+func (sf *SoundFont) resolveRegions(presetZones []zone, instruments [][]zone) []*Region {
+	regions := make([]*Region, 0)
+
+	var presetGlobal generators
+	for i, pz := range presetZones {
+		if pz.target < 0 {
+			if i == 0 {
+				presetGlobal = pz.gens
+			}
+			continue
+		}
+		if pz.target >= len(instruments) {
+			continue
+		}
+		pgens := merge(presetGlobal, pz.gens)
+
+		var instGlobal generators
+		for j, iz := range instruments[pz.target] {
+			if iz.target < 0 {
+				if j == 0 {
+					instGlobal = iz.gens
+				}
+				continue
+			}
+			if iz.target >= len(sf.Samples) {
+				continue
+			}
+			region := newRegion(sf.Samples[iz.target], merge(instGlobal, iz.gens), pgens)
+			if region != nil {
+				regions = append(regions, region)
+			}
+		}
+	}
+
+	return regions
+}
+
+// merge returns base overridden by every generator set in local.
+func merge(base, local generators) generators {
+	for op := range local.values {
+		if local.set[op] {
+			base.values[op] = local.values[op]
+			base.set[op] = true
+		}
+	}
+	return base
+}
+
+// newRegion builds a region from instrument-level generators inst and
+// preset-level generators preset. It returns nil if the key or velocity
+// ranges of the two levels do not overlap.
+func newRegion(sample *Sample, inst, preset generators) *Region {
+	region := &Region{Sample: sample}
+
+	keyLow, keyHigh := inst.byteRange(genKeyRange)
+	pKeyLow, pKeyHigh := preset.byteRange(genKeyRange)
+	velLow, velHigh := inst.byteRange(genVelRange)
+	pVelLow, pVelHigh := preset.byteRange(genVelRange)
+	region.KeyLow, region.KeyHigh = maxByte(keyLow, pKeyLow), minByte(keyHigh, pKeyHigh)
+	region.VelLow, region.VelHigh = maxByte(velLow, pVelLow), minByte(velHigh, pVelHigh)
+	if region.KeyLow > region.KeyHigh || region.VelLow > region.VelHigh {
+		return nil
+	}
+
+	// value returns the instrument value (or def) plus the preset offset.
+	value := func(op int, def int16) float64 {
+		v := float64(def)
+		if inst.set[op] {
+			v = float64(inst.values[op])
+		}
+		return v + float64(preset.values[op])
+	}
+	offset := func(fine, coarse int) int64 {
+		return int64(inst.values[fine]) + int64(inst.values[coarse])*32768
+	}
+
+	region.Start = clampOffset(sample.Start, offset(genStartAddrsOffset, genStartAddrsCoarseOffset))
+	region.End = clampOffset(sample.End, offset(genEndAddrsOffset, genEndAddrsCoarseOffset))
+	region.LoopStart = clampOffset(sample.LoopStart, offset(genStartloopAddrsOffset, genStartloopCoarseOffset))
+	region.LoopEnd = clampOffset(sample.LoopEnd, offset(genEndloopAddrsOffset, genEndloopCoarseOffset))
+	region.LoopMode = LoopMode(inst.values[genSampleModes] & 3)
+	if region.LoopEnd <= region.LoopStart || region.LoopEnd > region.End {
+		region.LoopMode = LoopNone
+	}
+
+	region.RootKey = int(sample.OriginalPitch)
+	if inst.set[genOverridingRootKey] && inst.values[genOverridingRootKey] >= 0 {
+		region.RootKey = int(inst.values[genOverridingRootKey])
+	}
+	region.Tune = value(genCoarseTune, 0)*100 + value(genFineTune, 0) + float64(sample.PitchCorrection)
+	region.ScaleTuning = value(genScaleTuning, 100)
+	region.Attenuation = value(genInitialAttenuation, 0)
+	region.Pan = math.Max(-1, math.Min(1, value(genPan, 0)/500))
+
+	region.Envelope = Envelope{
+		Delay:   timecents(value(genDelayVolEnv, -12000)),
+		Attack:  timecents(value(genAttackVolEnv, -12000)),
+		Hold:    timecents(value(genHoldVolEnv, -12000)),
+		Decay:   timecents(value(genDecayVolEnv, -12000)),
+		Sustain: centibels(value(genSustainVolEnv, 0)),
+		Release: timecents(value(genReleaseVolEnv, -12000)),
+	}
+
+	return region
+}
+
+// timecents converts an SF2 time in timecents to seconds.
+func timecents(tc float64) float64 {
+	if tc <= -12000 {
+		return 0
+	}
+	return math.Pow(2, tc/1200)
+}
+
+// centibels converts an SF2 attenuation in centibels to a linear gain.
+func centibels(cb float64) float64 {
+	if cb <= 0 {
+		return 1
+	}
+	return math.Pow(10, -cb/200)
+}
+
+func clampOffset(base uint32, offset int64) uint32 {
+	v := int64(base) + offset
+	if v < 0 {
+		return 0
+	}
+	if v > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(v)
+}
+
+func minByte(a, b byte) byte {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxByte(a, b byte) byte {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// riffChunk is a chunk of a RIFF file.
+type riffChunk struct {
+	id   string
+	data []byte
+}
+
+// readChunks splits data into consecutive RIFF chunks.
+func readChunks(data []byte) ([]riffChunk, error) {
+	chunks := make([]riffChunk, 0)
+	for pos := 0; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		start := pos + 8
+		if size > len(data)-start {
+			return nil, fmt.Errorf("%w: %s chunk truncated", ErrInvalidSoundFont, id)
+		}
+		chunks = append(chunks, riffChunk{id: id, data: data[start : start+size]})
+		pos = start + size + size%2
+	}
+	return chunks, nil
+}
+
+// cString returns the NUL-terminated string at the start of b.
+func cString(b []byte) string {
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		return string(b[:i])
+	}
+	return string(b)
+}
@@ -0,0 +1,192 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/klejdi94/git2midi/midi"
+)
+
+func TestParseSoundFont(t *testing.T) {
+	font, err := ParseSoundFont(buildSoundFont())
+	if err != nil {
+		t.Fatalf("ParseSoundFont failed: %v", err)
+	}
+
+	if font.Name != "Test Font" {
+		t.Errorf("name: got %q, want %q", font.Name, "Test Font")
+	}
+	if len(font.Samples) != 1 || len(font.Presets) != 1 {
+		t.Fatalf("got %d samples and %d presets, want 1 and 1", len(font.Samples), len(font.Presets))
+	}
+
+	preset := font.Presets[0]
+	if preset.Name != "Sine" || preset.Bank != 0 || preset.Program != 5 {
+		t.Errorf("preset: got %q bank %d program %d", preset.Name, preset.Bank, preset.Program)
+	}
+	if len(preset.Regions) != 1 {
+		t.Fatalf("regions: got %d, want 1", len(preset.Regions))
+	}
+
+	region := preset.Regions[0]
+	if region.KeyLow != 36 || region.KeyHigh != 96 {
+		t.Errorf("key range: got %d-%d, want 36-96", region.KeyLow, region.KeyHigh)
+	}
+	if region.LoopMode != LoopContinuous || region.LoopStart != 10 || region.LoopEnd != 90 {
+		t.Errorf("loop: got mode %d %d-%d", region.LoopMode, region.LoopStart, region.LoopEnd)
+	}
+	if region.RootKey != 69 || region.Tune != 7 {
+		t.Errorf("tuning: got root %d tune %.0f", region.RootKey, region.Tune)
+	}
+
+	if len(preset.Match(60, 100)) != 1 || len(preset.Match(20, 100)) != 0 {
+		t.Errorf("Match does not honor the key range")
+	}
+	if font.Preset(128, 0) != preset {
+		t.Errorf("Preset does not fall back to the first preset")
+	}
+}
+
+func TestParseSoundFontErrors(t *testing.T) {
+	valid := buildSoundFont()
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "empty", input: nil},
+		{name: "not sfbk", input: append([]byte("RIFF\x04\x00\x00\x00WAVE"), valid[12:]...)},
+		{name: "truncated", input: valid[:len(valid)-20]},
+		{name: "RIFF size too small", input: append([]byte("RIFF\x01\x00\x00\x00sfbk"), valid[12:]...)},
+		{name: "RIFF size zero", input: append([]byte("RIFF\x00\x00\x00\x00sfbk"), valid[12:]...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSoundFont(tt.input); !errors.Is(err, ErrInvalidSoundFont) {
+				t.Errorf("error: got %v, want %v", err, ErrInvalidSoundFont)
+			}
+		})
+	}
+}
+
+func TestSoundFontRender(t *testing.T) {
+	font, err := ParseSoundFont(buildSoundFont())
+	if err != nil {
+		t.Fatalf("ParseSoundFont failed: %v", err)
+	}
+
+	writer := midi.NewWriter(0, 480)
+	track := midi.NewTrack()
+	track.AddNoteOn(0, 0, 69, 127)
+	track.AddNoteOff(480, 0, 69, 64)
+	track.AddEndOfTrack(0)
+	writer.AddTrack(track)
+
+	buf, err := NewSoundFontRenderer(font).Render(writer)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	// 480 ticks at the default 120 BPM is half a second.
+	if buf.Frames() < DefaultSampleRate/2 {
+		t.Errorf("frames: got %d, want at least %d", buf.Frames(), DefaultSampleRate/2)
+	}
+
+	peak := float32(0)
+	for _, s := range buf.Samples {
+		if s > peak {
+			peak = s
+		}
+	}
+	if peak == 0 {
+		t.Errorf("rendered buffer is silent")
+	}
+
+	var wav bytes.Buffer
+	if err := buf.Encode(&wav); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if wav.Len() != 44+len(buf.Samples)*2 {
+		t.Errorf("WAV size: got %d, want %d", wav.Len(), 44+len(buf.Samples)*2)
+	}
+}
+
+// buildSoundFont assembles a minimal SoundFont with one looping sine sample,
+// one instrument and one preset (bank 0, program 5).
+func buildSoundFont() []byte {
+	samples := make([]byte, 0, 2*(100+46))
+	for i := 0; i < 100; i++ {
+		v := int16(math.Sin(2*math.Pi*float64(i)/80) * 16000)
+		samples = binary.LittleEndian.AppendUint16(samples, uint16(v))
+	}
+	samples = append(samples, make([]byte, 2*46)...)
+
+	name := func(s string) []byte {
+		b := make([]byte, 20)
+		copy(b, s)
+		return b
+	}
+	le16 := func(b []byte, v ...uint16) []byte {
+		for _, x := range v {
+			b = binary.LittleEndian.AppendUint16(b, x)
+		}
+		return b
+	}
+	le32 := func(b []byte, v ...uint32) []byte {
+		for _, x := range v {
+			b = binary.LittleEndian.AppendUint32(b, x)
+		}
+		return b
+	}
+
+	var phdr []byte
+	phdr = le32(le16(append(phdr, name("Sine")...), 5, 0, 0), 0, 0, 0)
+	phdr = le32(le16(append(phdr, name("EOP")...), 0, 0, 1), 0, 0, 0)
+	pbag := le16(nil, 0, 0, 1, 0)
+	pgen := le16(nil, genInstrument, 0, 0, 0)
+
+	var inst []byte
+	inst = le16(append(inst, name("Sine")...), 0)
+	inst = le16(append(inst, name("EOI")...), 1)
+	ibag := le16(nil, 0, 0, 4, 0)
+	igen := le16(nil,
+		genKeyRange, 36|96<<8,
+		genSampleModes, 1,
+		genFineTune, 7,
+		genSampleID, 0,
+		0, 0,
+	)
+
+	var shdr []byte
+	shdr = append(le32(append(shdr, name("sine")...), 0, 100, 10, 90, 32000), 69, 0, 0, 0, 1, 0)
+	shdr = append(shdr, make([]byte, 46)...)
+
+	chunk := func(id string, data []byte) []byte {
+		out := le32([]byte(id), uint32(len(data)))
+		out = append(out, data...)
+		if len(data)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	list := func(kind string, chunks ...[]byte) []byte {
+		data := []byte(kind)
+		for _, c := range chunks {
+			data = append(data, c...)
+		}
+		return chunk("LIST", data)
+	}
+
+	body := []byte("sfbk")
+	body = append(body, list("INFO", chunk("ifil", le16(nil, 2, 1)), chunk("INAM", []byte("Test Font\x00")))...)
+	body = append(body, list("sdta", chunk("smpl", samples))...)
+	body = append(body, list("pdta",
+		chunk("phdr", phdr), chunk("pbag", pbag), chunk("pmod", make([]byte, 10)), chunk("pgen", pgen),
+		chunk("inst", inst), chunk("ibag", ibag), chunk("imod", make([]byte, 10)), chunk("igen", igen),
+		chunk("shdr", shdr),
+	)...)
+	return chunk("RIFF", body)
+}
@@ -0,0 +1,242 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// Waveform is the shape of a synthesizer oscillator.
+type Waveform int
+
+const (
+	// WaveSine is a pure sine wave.
+	WaveSine Waveform = iota
+	// WaveSquare is a square wave.
+	WaveSquare
+	// WaveSaw is a rising sawtooth wave.
+	WaveSaw
+	// WaveTriangle is a triangle wave.
+	WaveTriangle
+	// WaveNoise is white noise, used for percussion.
+	WaveNoise
+)
+
+// Envelope is an ADSR amplitude envelope with optional delay and hold
+// stages. Times are in seconds and Sustain is a level between 0 and 1.
+type Envelope struct {
+	Delay   float64
+	Attack  float64
+	Hold    float64
+	Decay   float64
+	Sustain float64
+	Release float64
+}
+
+// level returns the envelope amplitude t seconds after the note started,
+// for a note held for gate seconds.
+func (e Envelope) level(t, gate float64) float64 {
+	if t < gate {
+		return e.held(t)
+	}
+	if e.Release <= 0 {
+		return 0
+	}
+	released := t - gate
+	if released >= e.Release {
+		return 0
+	}
+	return e.held(gate) * (1 - released/e.Release)
+}
+
+// held returns the envelope amplitude while the key is down.
+func (e Envelope) held(t float64) float64 {
+	if t < e.Delay {
+		return 0
+	}
+	t -= e.Delay
+	if t < e.Attack {
+		return t / e.Attack
+	}
+	t -= e.Attack + e.Hold
+	if t < 0 {
+		return 1
+	}
+	if t < e.Decay {
+		return 1 - (1-e.Sustain)*t/e.Decay
+	}
+	return e.Sustain
+}
+
+// Patch is a synthesizer sound: an oscillator shape and its envelope.
+type Patch struct {
+	Waveform Waveform
+	Envelope Envelope
+}
+
+var (
+	pluck   = Envelope{Attack: 0.005, Decay: 0.4, Sustain: 0.3, Release: 0.2}
+	sustain = Envelope{Attack: 0.01, Decay: 0.1, Sustain: 0.8, Release: 0.1}
+	bowed   = Envelope{Attack: 0.08, Decay: 0.2, Sustain: 0.8, Release: 0.3}
+	pad     = Envelope{Attack: 0.3, Decay: 0.3, Sustain: 0.8, Release: 0.6}
+
+	// DefaultPatches holds one patch per General MIDI instrument family.
+	DefaultPatches = [16]Patch{
+		{Waveform: WaveTriangle, Envelope: pluck},   // Piano
+		{Waveform: WaveSine, Envelope: pluck},       // Chromatic Percussion
+		{Waveform: WaveSquare, Envelope: sustain},   // Organ
+		{Waveform: WaveSaw, Envelope: pluck},        // Guitar
+		{Waveform: WaveTriangle, Envelope: sustain}, // Bass
+		{Waveform: WaveSaw, Envelope: bowed},        // Strings
+		{Waveform: WaveSaw, Envelope: bowed},        // Ensemble
+		{Waveform: WaveSaw, Envelope: sustain},      // Brass
+		{Waveform: WaveSquare, Envelope: sustain},   // Reed
+		{Waveform: WaveSine, Envelope: bowed},       // Pipe
+		{Waveform: WaveSquare, Envelope: sustain},   // Synth Lead
+		{Waveform: WaveTriangle, Envelope: pad},     // Synth Pad
+		{Waveform: WaveSine, Envelope: pad},         // Synth Effects
+		{Waveform: WaveSaw, Envelope: pluck},        // Ethnic
+		{Waveform: WaveSine, Envelope: pluck},       // Percussive
+		{Waveform: WaveNoise, Envelope: sustain},    // Sound Effects
+	}
+
+	// DrumPatch is a short noise burst for the percussion channel.
+	DrumPatch = Patch{
+		Waveform: WaveNoise,
+		Envelope: Envelope{Attack: 0.001, Decay: 0.12, Sustain: 0, Release: 0.05},
+	}
+)
+
+// Synth is a pure-Go software synthesizer that renders MIDI to audio using
+// basic oscillators and ADSR envelopes.
+type Synth struct {
+	SampleRate int
+	// Patches selects the sound for each General MIDI instrument family,
+	// following Program Change events.
+	Patches [16]Patch
+	// Drums is the sound of the percussion channel.
+	Drums Patch
+	// Gain is the amplitude of a full-velocity note before mixing.
+	Gain float64
+}
+
+// NewSynth creates a synthesizer with a basic patch for each General MIDI
+// instrument family and noise on the drum channel.
+func NewSynth() *Synth {
+	return &Synth{
+		SampleRate: DefaultSampleRate,
+		Patches:    DefaultPatches,
+		Drums:      DrumPatch,
+		Gain:       0.25,
+	}
+}
+
+// Render synthesizes the MIDI file held by w into a stereo buffer.
+func (s *Synth) Render(w *midi.Writer) (*Buffer, error) {
+	if s.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate: %d", s.SampleRate)
+	}
+
+	voices, length := sequence(w)
+	tail := s.Drums.Envelope.Release
+	for _, patch := range s.Patches {
+		if patch.Envelope.Release > tail {
+			tail = patch.Envelope.Release
+		}
+	}
+
+	buf := newBuffer(s.SampleRate, length+tail)
+	for _, v := range voices {
+		s.renderVoice(buf, v)
+	}
+	buf.Normalize(0.99)
+
+	return buf, nil
+}
+
+// Name returns "synth".
+func (s *Synth) Name() string {
+	return "synth"
+}
+
+// Available always returns nil: the built-in synthesizer needs nothing external.
+func (s *Synth) Available() error {
+	return nil
+}
+
+// RenderFile renders the MIDI file at midiPath to a WAV file at wavPath.
+func (s *Synth) RenderFile(midiPath, wavPath string) error {
+	w, err := midi.ReadFile(midiPath)
+	if err != nil {
+		return fmt.Errorf("failed to read MIDI file: %w", err)
+	}
+
+	buf, err := s.Render(w)
+	if err != nil {
+		return err
+	}
+	return buf.WriteWAV(wavPath)
+}
+
+// renderVoice mixes a single note into buf.
+func (s *Synth) renderVoice(buf *Buffer, v voice) {
+	patch := s.Patches[(v.program&0x7F)/8]
+	if v.channel == DrumChannel {
+		patch = s.Drums
+	}
+	wave, env := patch.Waveform, patch.Envelope
+
+	rate := float64(s.SampleRate)
+	gate := v.end - v.start
+	first := int(v.start * rate)
+	last := int((v.end + env.Release) * rate)
+	if last >= buf.Frames() {
+		last = buf.Frames() - 1
+	}
+
+	freq := 440 * math.Pow(2, (float64(v.key)+v.bend-69)/12)
+	amplitude := s.Gain * v.gain * float64(v.velocity) / 127
+	left := amplitude * math.Cos((v.pan+1)*math.Pi/4)
+	right := amplitude * math.Sin((v.pan+1)*math.Pi/4)
+	noise := uint32(v.key)*2654435761 + uint32(first) | 1
+
+	for i := first; i <= last; i++ {
+		t := float64(i-first) / rate
+		level := env.level(t, gate)
+		if level <= 0 && t >= gate {
+			break
+		}
+
+		var sample float64
+		if wave == WaveNoise {
+			noise ^= noise << 13
+			noise ^= noise >> 17
+			noise ^= noise << 5
+			sample = float64(noise)/math.MaxUint32*2 - 1
+		} else {
+			sample = oscillator(wave, math.Mod(freq*t, 1))
+		}
+
+		value := sample * level
+		buf.Samples[2*i] += float32(value * left)
+		buf.Samples[2*i+1] += float32(value * right)
+	}
+}
+
+// oscillator returns the value of wave at phase (0 to 1).
+func oscillator(wave Waveform, phase float64) float64 {
+	switch wave {
+	case WaveSquare:
+		if phase < 0.5 {
+			return 1
+		}
+		return -1
+	case WaveSaw:
+		return 2*phase - 1
+	case WaveTriangle:
+		return 1 - 4*math.Abs(phase-0.5)
+	default:
+		return math.Sin(2 * math.Pi * phase)
+	}
+}
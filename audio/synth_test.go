@@ -0,0 +1,37 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	shaped := Envelope{Delay: 0.1, Attack: 0.2, Hold: 0.1, Decay: 0.2, Sustain: 0.5, Release: 0.4}
+
+	tests := []struct {
+		name     string
+		envelope Envelope
+		t, gate  float64
+		want     float64
+	}{
+		{name: "delay", envelope: shaped, t: 0.05, gate: 1, want: 0},
+		{name: "attack start", envelope: shaped, t: 0.1, gate: 1, want: 0},
+		{name: "attack midpoint", envelope: shaped, t: 0.2, gate: 1, want: 0.5},
+		{name: "hold", envelope: shaped, t: 0.35, gate: 1, want: 1},
+		{name: "decay midpoint", envelope: shaped, t: 0.5, gate: 1, want: 0.75},
+		{name: "sustain", envelope: shaped, t: 0.9, gate: 1, want: 0.5},
+		{name: "release midpoint", envelope: shaped, t: 1.2, gate: 1, want: 0.25},
+		{name: "released", envelope: shaped, t: 1.4, gate: 1, want: 0},
+		{name: "released during attack", envelope: shaped, t: 0.3, gate: 0.2, want: 0.375},
+		{name: "no attack", envelope: Envelope{Sustain: 1}, t: 0, gate: 1, want: 1},
+		{name: "no release", envelope: Envelope{Sustain: 1}, t: 1, gate: 1, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.envelope.level(tt.t, tt.gate); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("level(%g, %g) = %g, want %g", tt.t, tt.gate, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// DefaultSampleRate is the sample rate used for rendered audio.
+const DefaultSampleRate = 44100
+
+// Buffer holds interleaved stereo audio samples in the range [-1, 1].
+type Buffer struct {
+	SampleRate int
+	Samples    []float32
+}
+
+// newBuffer allocates a silent stereo buffer long enough for seconds of audio.
+func newBuffer(sampleRate int, seconds float64) *Buffer {
+	frames := int(math.Ceil(seconds*float64(sampleRate))) + 1
+	return &Buffer{
+		SampleRate: sampleRate,
+		Samples:    make([]float32, frames*2),
+	}
+}
+
+// Frames returns the number of stereo frames in the buffer.
+func (b *Buffer) Frames() int {
+	return len(b.Samples) / 2
+}
+
+// Normalize scales the buffer down so its peak does not exceed level.
+// Quieter buffers are left unchanged.
+func (b *Buffer) Normalize(level float32) {
+	peak := float32(0)
+	for _, s := range b.Samples {
+		if s > peak {
+			peak = s
+		} else if -s > peak {
+			peak = -s
+		}
+	}
+	if peak <= level {
+		return
+	}
+
+	gain := level / peak
+	for i := range b.Samples {
+		b.Samples[i] *= gain
+	}
+}
+
+// WriteWAV writes the buffer to path as a 16-bit PCM stereo WAV file.
+func (b *Buffer) WriteWAV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if err := b.Encode(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Encode writes the buffer as a 16-bit PCM stereo WAV stream.
+func (b *Buffer) Encode(w io.Writer) error {
+	const (
+		channels      = 2
+		bitsPerSample = 16
+	)
+	blockAlign := channels * bitsPerSample / 8
+	dataSize := uint32(len(b.Samples) * bitsPerSample / 8)
+
+	out := bufio.NewWriter(w)
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(36 + dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16),
+		uint16(1), // PCM
+		uint16(channels),
+		uint32(b.SampleRate),
+		uint32(b.SampleRate * blockAlign),
+		uint16(blockAlign),
+		uint16(bitsPerSample),
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize,
+	}
+	for _, field := range header {
+		if err := binary.Write(out, binary.LittleEndian, field); err != nil {
+			return fmt.Errorf("failed to write WAV header: %w", err)
+		}
+	}
+
+	var sample [2]byte
+	for _, s := range b.Samples {
+		if s > 1 {
+			s = 1
+		} else if s < -1 {
+			s = -1
+		}
+		binary.LittleEndian.PutUint16(sample[:], uint16(int16(s*math.MaxInt16)))
+		if _, err := out.Write(sample[:]); err != nil {
+			return fmt.Errorf("failed to write WAV data: %w", err)
+		}
+	}
+
+	return out.Flush()
+}
@@ -0,0 +1,75 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteWAV(t *testing.T) {
+	buf := &Buffer{SampleRate: 22050, Samples: []float32{0, 0.5, -0.5, 1, 2, -2}}
+
+	path := filepath.Join(t.TempDir(), "out.wav")
+	if err := buf.WriteWAV(path); err != nil {
+		t.Fatalf("WriteWAV failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header struct {
+		RIFF          [4]byte
+		RIFFSize      uint32
+		WAVE          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}
+	reader := bytes.NewReader(data)
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		t.Fatalf("failed to read header: %v", err)
+	}
+
+	if string(header.RIFF[:]) != "RIFF" || string(header.WAVE[:]) != "WAVE" ||
+		string(header.Fmt[:]) != "fmt " || string(header.Data[:]) != "data" {
+		t.Errorf("chunk IDs: got %q %q %q %q", header.RIFF, header.WAVE, header.Fmt, header.Data)
+	}
+	if header.RIFFSize != uint32(len(data)-8) {
+		t.Errorf("RIFF size: got %d, want %d", header.RIFFSize, len(data)-8)
+	}
+	if header.DataSize != uint32(reader.Len()) {
+		t.Errorf("data size: got %d, want %d", header.DataSize, reader.Len())
+	}
+	if header.Format != 1 || header.Channels != 2 || header.BitsPerSample != 16 || header.BlockAlign != 4 {
+		t.Errorf("format: got %d, %d channels, %d bits, block align %d; want 16-bit PCM stereo",
+			header.Format, header.Channels, header.BitsPerSample, header.BlockAlign)
+	}
+	if header.SampleRate != 22050 || header.ByteRate != 22050*4 {
+		t.Errorf("rate: got %d Hz, %d bytes/s; want 22050 Hz, %d bytes/s", header.SampleRate, header.ByteRate, 22050*4)
+	}
+	if frames := int(header.DataSize) / int(header.BlockAlign); frames != buf.Frames() {
+		t.Errorf("frames: got %d, want %d", frames, buf.Frames())
+	}
+
+	samples := make([]int16, len(buf.Samples))
+	if err := binary.Read(reader, binary.LittleEndian, samples); err != nil {
+		t.Fatalf("failed to read samples: %v", err)
+	}
+	// Samples outside [-1, 1] are clipped.
+	want := []int16{0, 16383, -16383, 32767, 32767, -32767}
+	for i := range want {
+		if samples[i] != want[i] {
+			t.Errorf("sample %d: got %d, want %d", i, samples[i], want[i])
+		}
+	}
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/klejdi94/git2midi/music"
 )
 
 // Config holds all configuration for the MIDI generation process.
@@ -15,6 +18,56 @@ type Config struct {
 	MaxCommits int
 	Sample     bool
 	Mode       Mode
+
+	// Backend reads the repository: "auto", "git" or "native".
+	Backend string
+
+	// AliasFile maps alternative author names and emails to one person.
+	AliasFile string
+
+	// Revision, All, Since, Until, Author, ExcludeAuthor and Paths select
+	// the commits read from the repository.
+	Revision      string
+	All           bool
+	Since         string
+	Until         string
+	Author        string
+	ExcludeAuthor string
+	Paths         []string
+
+	Rhythm       Rhythm
+	Compression  Compression
+	BeatDuration time.Duration
+	Grid         int
+	MaxSilence   int
+
+	Scale string
+	Key   string
+	Range string
+
+	Soundfont string
+	Renderer  string
+
+	Instrument        string
+	AuthorInstruments string
+
+	Lyrics  Lyrics
+	Karaoke bool
+
+	TagAccent    Accent
+	MarkBranches bool
+
+	Drums   bool
+	Harmony Harmony
+
+	// Conventional plays commits by their Conventional Commits type, and
+	// KeyChange is the key change in semitones at breaking changes.
+	Conventional bool
+	KeyChange    int
+
+	Allocation       Allocation
+	Voices           int
+	CoAuthorInterval int
 }
 
 // Mode represents the generation mode.
@@ -26,6 +79,9 @@ const (
 
 	// ModePerAuthor generates separate tracks for each author.
 	ModePerAuthor
+
+	// ModeLanes generates separate tracks for each branch lane.
+	ModeLanes
 )
 
 // String returns the string representation of the mode.
@@ -35,6 +91,8 @@ func (m Mode) String() string {
 		return "single-track"
 	case ModePerAuthor:
 		return "per-author"
+	case ModeLanes:
+		return "lanes"
 	default:
 		return "unknown"
 	}
@@ -47,8 +105,255 @@ func ParseMode(s string) (Mode, error) {
 		return ModeSingleTrack, nil
 	case "per-author":
 		return ModePerAuthor, nil
+	case "lanes":
+		return ModeLanes, nil
+	default:
+		return ModeSingleTrack, fmt.Errorf("invalid mode: %s (must be 'single-track', 'per-author' or 'lanes')", s)
+	}
+}
+
+// Rhythm represents how commits are spaced in time.
+type Rhythm int
+
+const (
+	// RhythmFixed uses the built-in rhythm pattern.
+	RhythmFixed Rhythm = iota
+
+	// RhythmTimestamp spaces commits by the real time between them.
+	RhythmTimestamp
+)
+
+// String returns the string representation of the rhythm.
+func (r Rhythm) String() string {
+	switch r {
+	case RhythmFixed:
+		return "fixed"
+	case RhythmTimestamp:
+		return "timestamp"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRhythm parses a rhythm string into a Rhythm value.
+func ParseRhythm(s string) (Rhythm, error) {
+	switch s {
+	case "fixed":
+		return RhythmFixed, nil
+	case "timestamp":
+		return RhythmTimestamp, nil
+	default:
+		return RhythmFixed, fmt.Errorf("invalid rhythm: %s (must be 'fixed' or 'timestamp')", s)
+	}
+}
+
+// Compression represents how wall-clock gaps are mapped to musical time.
+type Compression int
+
+const (
+	// CompressionLinear maps gaps proportionally.
+	CompressionLinear Compression = iota
+
+	// CompressionLog maps gaps logarithmically.
+	CompressionLog
+
+	// CompressionQuantized maps gaps proportionally, snapped to a grid.
+	CompressionQuantized
+)
+
+// String returns the string representation of the compression.
+func (c Compression) String() string {
+	switch c {
+	case CompressionLinear:
+		return "linear"
+	case CompressionLog:
+		return "log"
+	case CompressionQuantized:
+		return "quantize"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseCompression parses a compression string into a Compression value.
+func ParseCompression(s string) (Compression, error) {
+	switch s {
+	case "linear":
+		return CompressionLinear, nil
+	case "log":
+		return CompressionLog, nil
+	case "quantize":
+		return CompressionQuantized, nil
+	default:
+		return CompressionLinear, fmt.Errorf("invalid compression: %s (must be 'linear', 'log' or 'quantize')", s)
+	}
+}
+
+// Lyrics represents how commit subjects are added as lyrics.
+type Lyrics int
+
+const (
+	// LyricsOff adds no lyrics.
+	LyricsOff Lyrics = iota
+
+	// LyricsSubject adds each subject as one lyric.
+	LyricsSubject
+
+	// LyricsWords adds each word of a subject as its own lyric.
+	LyricsWords
+)
+
+// String returns the string representation of the lyrics mode.
+func (l Lyrics) String() string {
+	switch l {
+	case LyricsOff:
+		return "off"
+	case LyricsSubject:
+		return "subject"
+	case LyricsWords:
+		return "words"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLyrics parses a lyrics string into a Lyrics value.
+func ParseLyrics(s string) (Lyrics, error) {
+	switch s {
+	case "off":
+		return LyricsOff, nil
+	case "subject":
+		return LyricsSubject, nil
+	case "words":
+		return LyricsWords, nil
 	default:
-		return ModeSingleTrack, fmt.Errorf("invalid mode: %s (must be 'single-track' or 'per-author')", s)
+		return LyricsOff, fmt.Errorf("invalid lyrics: %s (must be 'off', 'subject' or 'words')", s)
+	}
+}
+
+// Accent represents the musical gesture played at tagged commits.
+type Accent int
+
+const (
+	// AccentNone only marks tagged commits.
+	AccentNone Accent = iota
+
+	// AccentCadence resolves a cadence on tagged commits.
+	AccentCadence
+
+	// AccentCymbal plays a crash cymbal on tagged commits.
+	AccentCymbal
+)
+
+// String returns the string representation of the accent.
+func (a Accent) String() string {
+	switch a {
+	case AccentNone:
+		return "none"
+	case AccentCadence:
+		return "cadence"
+	case AccentCymbal:
+		return "cymbal"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseAccent parses an accent string into an Accent value.
+func ParseAccent(s string) (Accent, error) {
+	switch s {
+	case "none":
+		return AccentNone, nil
+	case "cadence":
+		return AccentCadence, nil
+	case "cymbal":
+		return AccentCymbal, nil
+	default:
+		return AccentNone, fmt.Errorf("invalid tag accent: %s (must be 'none', 'cadence' or 'cymbal')", s)
+	}
+}
+
+// Harmony represents how the history is divided into chords.
+type Harmony int
+
+const (
+	// HarmonyOff plays the melody alone.
+	HarmonyOff Harmony = iota
+
+	// HarmonyWeek changes chord every calendar week.
+	HarmonyWeek
+
+	// HarmonyMonth changes chord every calendar month.
+	HarmonyMonth
+
+	// HarmonyCluster changes chord after every pause in activity.
+	HarmonyCluster
+)
+
+// String returns the string representation of the harmony.
+func (h Harmony) String() string {
+	switch h {
+	case HarmonyOff:
+		return "off"
+	case HarmonyWeek:
+		return "week"
+	case HarmonyMonth:
+		return "month"
+	case HarmonyCluster:
+		return "cluster"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseHarmony parses a harmony string into a Harmony value.
+func ParseHarmony(s string) (Harmony, error) {
+	switch s {
+	case "off":
+		return HarmonyOff, nil
+	case "week":
+		return HarmonyWeek, nil
+	case "month":
+		return HarmonyMonth, nil
+	case "cluster":
+		return HarmonyCluster, nil
+	default:
+		return HarmonyOff, fmt.Errorf("invalid harmony: %s (must be 'off', 'week', 'month' or 'cluster')", s)
+	}
+}
+
+// Allocation represents how authors are assigned to voices in per-author mode.
+type Allocation int
+
+const (
+	// AllocationTop gives the most active authors their own voice.
+	AllocationTop Allocation = iota
+
+	// AllocationHash hashes authors onto channels.
+	AllocationHash
+)
+
+// String returns the string representation of the allocation.
+func (a Allocation) String() string {
+	switch a {
+	case AllocationTop:
+		return "top"
+	case AllocationHash:
+		return "hash"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseAllocation parses an allocation string into an Allocation value.
+func ParseAllocation(s string) (Allocation, error) {
+	switch s {
+	case "top":
+		return AllocationTop, nil
+	case "hash":
+		return AllocationHash, nil
+	default:
+		return AllocationTop, fmt.Errorf("invalid allocation: %s (must be 'top' or 'hash')", s)
 	}
 }
 
@@ -86,6 +391,34 @@ const (
 	// MaxDuration is the maximum allowed note duration in ticks.
 	MaxDuration = 4800
 
+	// DefaultBeatDuration is the default wall-clock gap mapped to one beat.
+	DefaultBeatDuration = time.Hour
+
+	// DefaultMaxSilence is the default cap on a single gap, in beats.
+	DefaultMaxSilence = 8
+
+	// DefaultScale is the default scale name.
+	DefaultScale = "pentatonic-minor"
+
+	// DefaultKey is the default root key.
+	DefaultKey = "C"
+
+	// DefaultRange is the default pitch range.
+	DefaultRange = "C4-C6"
+
+	// DefaultInstrument is the default General MIDI instrument.
+	DefaultInstrument = "Acoustic Grand Piano"
+
+	// DefaultBackend selects the first available git backend.
+	DefaultBackend = "auto"
+
+	// DefaultRenderer selects the first available audio renderer.
+	DefaultRenderer = "auto"
+
+	// DefaultCoAuthorInterval is the default number of scale steps between
+	// the voices of a commit's author and co-authors (a third).
+	DefaultCoAuthorInterval = 2
+
 	// RecommendedMaxCommits is the recommended maximum commits for reasonable file size.
 	RecommendedMaxCommits = 2000
 )
@@ -112,10 +445,58 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("duration must be between %d and %d, got %d", MinDuration, MaxDuration, c.Duration)
 	}
 
+	if c.All && c.Revision != "" {
+		return errors.New("a revision cannot be combined with all refs")
+	}
+
 	if c.MaxCommits < 0 {
 		return fmt.Errorf("max commits cannot be negative, got %d", c.MaxCommits)
 	}
 
+	if c.BeatDuration <= 0 {
+		return fmt.Errorf("beat duration must be positive, got %s", c.BeatDuration)
+	}
+
+	if c.Grid < 0 {
+		return fmt.Errorf("grid cannot be negative, got %d", c.Grid)
+	}
+
+	if c.MaxSilence < 0 {
+		return fmt.Errorf("max silence cannot be negative, got %d", c.MaxSilence)
+	}
+
+	if c.Voices < 0 {
+		return fmt.Errorf("voices cannot be negative, got %d", c.Voices)
+	}
+
+	if c.CoAuthorInterval < 0 {
+		return fmt.Errorf("co-author interval cannot be negative, got %d", c.CoAuthorInterval)
+	}
+
+	if c.Scale == "" {
+		return errors.New("scale cannot be empty")
+	}
+
+	if c.Key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	if c.Range == "" {
+		return errors.New("pitch range cannot be empty")
+	}
+
+	if c.Backend == "" {
+		return errors.New("backend cannot be empty")
+	}
+
+	if c.Renderer == "" {
+		return errors.New("renderer cannot be empty")
+	}
+
+	if c.Instrument == "" {
+		return errors.New("instrument cannot be empty")
+	}
+
 	return nil
 }
 
@@ -130,5 +511,31 @@ func NewConfig() *Config {
 		MaxCommits: 0,
 		Sample:     false,
 		Mode:       ModeSingleTrack,
+		Backend:    DefaultBackend,
+
+		Rhythm:       RhythmFixed,
+		Compression:  CompressionLog,
+		BeatDuration: DefaultBeatDuration,
+		Grid:         0,
+		MaxSilence:   DefaultMaxSilence,
+
+		Scale: DefaultScale,
+		Key:   DefaultKey,
+		Range: DefaultRange,
+
+		Renderer: DefaultRenderer,
+
+		Instrument: DefaultInstrument,
+
+		Lyrics: LyricsOff,
+
+		TagAccent: AccentNone,
+
+		Harmony: HarmonyOff,
+
+		KeyChange: music.DefaultKeyChange,
+
+		Allocation:       AllocationTop,
+		CoAuthorInterval: DefaultCoAuthorInterval,
 	}
 }
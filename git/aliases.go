@@ -0,0 +1,97 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Aliases maps alternative author names and emails, case-insensitively, to
+// the canonical name of the person they belong to.
+type Aliases map[string]string
+
+// LoadAliases reads an alias file. See ParseAliases for the format.
+func LoadAliases(path string) (Aliases, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open alias file: %w", err)
+	}
+	defer file.Close()
+
+	return ParseAliases(file)
+}
+
+// ParseAliases parses alias definitions, one person per line:
+//
+//	Jane Doe = jane, J. Doe, jane@users.noreply.example.com
+//
+// The name before "=" is the canonical name; the comma-separated names and
+// emails after it are replaced by it. Blank lines and lines starting with
+// "#" are ignored.
+func ParseAliases(r io.Reader) (Aliases, error) {
+	aliases := make(Aliases)
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, list, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("alias file line %d: expected \"name = alias, ...\"", lineNumber)
+		}
+
+		aliases[strings.ToLower(name)] = name
+		for _, alias := range strings.Split(list, ",") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				aliases[strings.ToLower(alias)] = name
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// Resolve returns the canonical name for an author, matching the email
+// first and then the name. Unknown authors keep their name.
+func (a Aliases) Resolve(name, email string) string {
+	if email != "" {
+		if canonical, ok := a[strings.ToLower(email)]; ok {
+			return canonical
+		}
+	}
+	if canonical, ok := a[strings.ToLower(name)]; ok {
+		return canonical
+	}
+	return name
+}
+
+// Apply replaces the author and co-authors of every commit with their
+// canonical names.
+func (a Aliases) Apply(commits []Commit) {
+	for i := range commits {
+		a.apply(&commits[i])
+	}
+}
+
+// apply replaces the author and co-authors of a commit with their canonical
+// names.
+func (a Aliases) apply(c *Commit) {
+	if len(a) == 0 {
+		return
+	}
+	c.Author = a.Resolve(c.Author, c.Email)
+	for j, coAuthor := range c.CoAuthors {
+		c.CoAuthors[j].Name = a.Resolve(coAuthor.Name, coAuthor.Email)
+	}
+}
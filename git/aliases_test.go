@@ -0,0 +1,39 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	aliases, err := ParseAliases(strings.NewReader(`
+# The core team
+Jane Doe = jane, J. Doe, jane@users.noreply.example.com
+Bob = bobby
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commits := []Commit{
+		{Author: "jane", Email: "jane@example.com"},
+		{Author: "J. DOE"},
+		{Author: "octocat", Email: "Jane@users.noreply.example.com"},
+		{Author: "bobby", Email: "bob@example.com"},
+		{Author: "Alice", Email: "alice@example.com"},
+	}
+	aliases.Apply(commits)
+
+	want := []string{"Jane Doe", "Jane Doe", "Jane Doe", "Bob", "Alice"}
+	for i := range want {
+		if commits[i].Author != want[i] {
+			t.Errorf("commit %d: got %q, want %q", i, commits[i].Author, want[i])
+		}
+	}
+}
+
+func TestParseAliasesError(t *testing.T) {
+	if _, err := ParseAliases(strings.NewReader("jane, J. Doe\n")); err == nil {
+		t.Error("expected an error for a line without a canonical name")
+	}
+}
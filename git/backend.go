@@ -0,0 +1,101 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Backend reads the commit history of a repository.
+type Backend interface {
+	// Name returns the backend name used to select it, e.g. "native".
+	Name() string
+	// Available returns nil if the backend can read repositories, or the
+	// reason it cannot.
+	Available() error
+	// OpenLog starts reading the commits selected by opts from a repository
+	// path or URL, as OpenLog does.
+	OpenLog(repoPath string, opts LogOptions) (LogReader, error)
+}
+
+// LogReader is a CommitIterator over a repository's history.
+type LogReader interface {
+	CommitIterator
+	// Skipped returns the number of commits so far that could not be parsed.
+	Skipped() int
+}
+
+// DefaultBackends returns every backend in order of preference: the git
+// binary, then the built-in reader.
+func DefaultBackends() []Backend {
+	return []Backend{Exec{}, Native{}}
+}
+
+// BackendNames returns the names of all backends.
+func BackendNames() []string {
+	names := make([]string, 0)
+	for _, b := range DefaultBackends() {
+		names = append(names, b.Name())
+	}
+	return names
+}
+
+// SelectBackend returns the named backend, or the first available one if
+// name is "auto" or empty.
+func SelectBackend(name string) (Backend, error) {
+	var reasons []string
+	for _, b := range DefaultBackends() {
+		if name != "" && name != "auto" && b.Name() != name {
+			continue
+		}
+		err := b.Available()
+		if err == nil {
+			return b, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", b.Name(), err))
+	}
+
+	if len(reasons) == 0 {
+		return nil, fmt.Errorf("unknown backend: %s (supported: auto, %s)", name, strings.Join(BackendNames(), ", "))
+	}
+	return nil, fmt.Errorf("no git backend available (%s)", strings.Join(reasons, "; "))
+}
+
+// ReadLog reads the commits selected by opts with a backend, oldest first,
+// counting the commits that could not be parsed.
+func ReadLog(backend Backend, repoPath string, opts LogOptions) (*Log, error) {
+	opts.Reverse = true
+	it, err := backend.OpenLog(repoPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	commits, err := Collect(it)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{Commits: commits, Skipped: it.Skipped()}, nil
+}
+
+// Exec reads history by running the git binary.
+type Exec struct{}
+
+// Name returns "git".
+func (Exec) Name() string {
+	return "git"
+}
+
+// Available reports whether git is on PATH.
+func (Exec) Available() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("executable not found in PATH")
+	}
+	return nil
+}
+
+// OpenLog starts git log; see the OpenLog function.
+func (Exec) OpenLog(repoPath string, opts LogOptions) (LogReader, error) {
+	return OpenLog(repoPath, opts)
+}
@@ -3,14 +3,96 @@ package git
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // Commit represents a parsed Git commit.
 type Commit struct {
-	Hash      string
+	Hash    string
+	Parents []string
+
+	// Timestamp is the commit (committer) time in Unix seconds.
 	Timestamp int64
 	Author    string
-	Message   string
+	Email     string
+
+	AuthorTimestamp int64
+	Committer       string
+	CommitterEmail  string
+
+	// Message is the subject line; Body is the rest of the message.
+	Message string
+	Body    string
+
+	// Diff statistics, as reported by git log --numstat.
+	Insertions   int
+	Deletions    int
+	FilesChanged int
+	Files        []string
+
+	// CoAuthors are the people credited with Co-authored-by trailers.
+	CoAuthors []Identity
+
+	// Type, Scope and Breaking classify a Conventional Commits subject
+	// ("feat(parser)!: add streaming"): Type is empty for other subjects,
+	// and a "BREAKING CHANGE:" footer marks the commit breaking too.
+	Type     string
+	Scope    string
+	Breaking bool
+
+	// Refs pointing at the commit: tag names and branch heads (local and
+	// remote-tracking), as reported by git log --decorate.
+	Tags     []string
+	Branches []string
+}
+
+// Identity is a person's name and email.
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// ParseIdentity parses an identity in "Name <email>" form. A string without
+// an email is taken as a bare name.
+func ParseIdentity(s string) Identity {
+	s = strings.TrimSpace(s)
+	bracket := strings.LastIndex(s, "<")
+	if bracket < 0 || !strings.HasSuffix(s, ">") {
+		return Identity{Name: s}
+	}
+	return Identity{
+		Name:  strings.TrimSpace(s[:bracket]),
+		Email: strings.TrimSpace(s[bracket+1 : len(s)-1]),
+	}
+}
+
+// ChangeSize returns the total number of changed lines in the commit.
+func (c *Commit) ChangeSize() int {
+	return c.Insertions + c.Deletions
+}
+
+// IsTagged reports whether any tag points at the commit.
+func (c *Commit) IsTagged() bool {
+	return len(c.Tags) > 0
+}
+
+// IsMerge reports whether the commit is a merge: it has more than one
+// parent or, for commits without parent information, a "Merge" subject.
+func (c *Commit) IsMerge() bool {
+	if len(c.Parents) > 0 {
+		return len(c.Parents) > 1
+	}
+	return strings.HasPrefix(c.Message, "Merge ")
+}
+
+// IsConventional reports whether the subject follows Conventional Commits.
+func (c *Commit) IsConventional() bool {
+	return c.Type != ""
+}
+
+// IsRevert reports whether the commit reverts another, judged by its subject.
+func (c *Commit) IsRevert() bool {
+	return strings.HasPrefix(c.Message, "Revert ")
 }
 
 // Validate validates the commit data.
@@ -53,4 +135,3 @@ func SampleCommits(commits []Commit, count int) ([]Commit, error) {
 
 	return sampled, nil
 }
-
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// conventionalSubject matches a Conventional Commits subject:
+// "type(scope)!: description", with the scope and "!" optional.
+var conventionalSubject = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)(?:\(([^()]*)\))?(!)?: +\S`)
+
+// breakingFooters are the footer tokens announcing a breaking change.
+var breakingFooters = []string{"BREAKING CHANGE:", "BREAKING-CHANGE:"}
+
+// parseConventional sets the commit's Conventional Commits type, scope and
+// breaking flag from its subject and body. Types are lower-cased, since the
+// specification treats them case-insensitively. Subjects not following the
+// convention leave the commit untouched.
+func parseConventional(commit *Commit) {
+	match := conventionalSubject.FindStringSubmatch(commit.Message)
+	if match == nil {
+		return
+	}
+
+	commit.Type = strings.ToLower(match[1])
+	commit.Scope = strings.TrimSpace(match[2])
+	commit.Breaking = match[3] == "!"
+
+	for _, line := range strings.Split(commit.Body, "\n") {
+		for _, footer := range breakingFooters {
+			if strings.HasPrefix(line, footer) {
+				commit.Breaking = true
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		subject, body string
+		typ, scope    string
+		breaking      bool
+	}{
+		{subject: "feat: add streaming", typ: "feat"},
+		{subject: "fix(parser): handle empty input", typ: "fix", scope: "parser"},
+		{subject: "Refactor(git)!: drop the exec fallback", typ: "refactor", scope: "git", breaking: true},
+		{subject: "chore: bump deps", body: "BREAKING CHANGE: requires Go 1.20", typ: "chore", breaking: true},
+		{subject: "docs: explain lanes", body: "Refs #12\nBREAKING-CHANGE: new layout", typ: "docs", breaking: true},
+		{subject: "ci: mention BREAKING CHANGE: inline", typ: "ci"},
+		{subject: "Merge branch 'feature'"},
+		{subject: "fix(parser: unbalanced scope"},
+		{subject: "feat:no space"},
+		{subject: "Revert \"feat: add streaming\""},
+		{subject: "Update README", body: "BREAKING CHANGE: ignored without a type"},
+	}
+
+	for _, tt := range tests {
+		commit := Commit{Message: tt.subject, Body: tt.body}
+		parseConventional(&commit)
+		if commit.Type != tt.typ || commit.Scope != tt.scope || commit.Breaking != tt.breaking {
+			t.Errorf("%q: got type %q scope %q breaking %v, want %q %q %v",
+				tt.subject, commit.Type, commit.Scope, commit.Breaking, tt.typ, tt.scope, tt.breaking)
+		}
+		if commit.IsConventional() != (tt.typ != "") {
+			t.Errorf("%q: IsConventional() = %v", tt.subject, commit.IsConventional())
+		}
+	}
+}
+
+func TestConventionalCommitsInLog(t *testing.T) {
+	f := newFixture(t)
+	f.commit(ann, "feat(parser): add streaming", nil)
+	f.commit(bob, "fix!: close packs", nil)
+	f.commit(carol, "chore: bump deps\n\nBREAKING CHANGE: requires Go 1.20", nil)
+	f.commit(ann, "Update README", nil)
+
+	type classification struct {
+		typ, scope string
+		breaking   bool
+	}
+	want := []classification{
+		{typ: "feat", scope: "parser"},
+		{typ: "fix", breaking: true},
+		{typ: "chore", breaking: true},
+		{},
+	}
+
+	exec, native := readBoth(t, f.dir, LogOptions{})
+	for name, log := range map[string]*Log{"git": exec, "native": native} {
+		var got []classification
+		for _, commit := range log.Commits {
+			got = append(got, classification{typ: commit.Type, scope: commit.Scope, breaking: commit.Breaking})
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s backend: got %+v, want %+v", name, got, want)
+		}
+	}
+}
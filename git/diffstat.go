@@ -0,0 +1,471 @@
+package git
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// fileChange is a file added, deleted, modified or renamed between two
+// trees. The hash and mode of a missing side are empty.
+type fileChange struct {
+	oldPath, newPath string
+	oldHash, newHash string
+	oldMode, newMode uint32
+}
+
+func (c fileChange) added() bool   { return c.oldHash == "" }
+func (c fileChange) deleted() bool { return c.newHash == "" }
+
+// path returns the path of the file after the change, or before it for a
+// deletion.
+func (c fileChange) path() string {
+	if c.deleted() {
+		return c.oldPath
+	}
+	return c.newPath
+}
+
+// diffTrees lists the files matching spec that differ between two trees,
+// in path order. An empty hash is the empty tree.
+func (r *repository) diffTrees(oldTree, newTree string, spec pathspec) ([]fileChange, error) {
+	var changes []fileChange
+	err := r.diffTreesAt("", oldTree, newTree, spec, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].path() < changes[j].path()
+	})
+	return changes, err
+}
+
+func (r *repository) diffTreesAt(dir, oldTree, newTree string, spec pathspec, changes *[]fileChange) error {
+	if oldTree == newTree {
+		return nil
+	}
+	oldEntries, err := r.readTree(oldTree)
+	if err != nil {
+		return err
+	}
+	newEntries, err := r.readTree(newTree)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string][2]treeEntry)
+	for _, entry := range oldEntries {
+		pair := entries[entry.name]
+		pair[0] = entry
+		entries[entry.name] = pair
+	}
+	for _, entry := range newEntries {
+		pair := entries[entry.name]
+		pair[1] = entry
+		entries[entry.name] = pair
+	}
+
+	for name, pair := range entries {
+		before, after := pair[0], pair[1]
+		if before.hash == after.hash && before.mode == after.mode {
+			continue
+		}
+		filePath := path.Join(dir, name)
+
+		// A tree on either side is descended into; a file turning into a
+		// directory, or back, is a deletion plus additions.
+		var oldSubtree, newSubtree string
+		if before.isTree() {
+			oldSubtree, before = before.hash, treeEntry{}
+		}
+		if after.isTree() {
+			newSubtree, after = after.hash, treeEntry{}
+		}
+		if (oldSubtree != "" || newSubtree != "") && spec.mayMatchUnder(filePath) {
+			if err := r.diffTreesAt(filePath, oldSubtree, newSubtree, spec, changes); err != nil {
+				return err
+			}
+		}
+
+		if (before.hash == "" && after.hash == "") || !spec.matches(filePath) {
+			continue
+		}
+		change := fileChange{oldHash: before.hash, newHash: after.hash, oldMode: before.mode, newMode: after.mode}
+		if before.hash != "" && after.hash != "" && (before.mode&0o170000 != after.mode&0o170000) {
+			// A change of file type, such as a file becoming a symlink.
+			*changes = append(*changes,
+				fileChange{oldPath: filePath, oldHash: before.hash, oldMode: before.mode},
+				fileChange{newPath: filePath, newHash: after.hash, newMode: after.mode})
+			continue
+		}
+		if before.hash != "" {
+			change.oldPath = filePath
+		}
+		if after.hash != "" {
+			change.newPath = filePath
+		}
+		*changes = append(*changes, change)
+	}
+	return nil
+}
+
+// pathspec limits paths to those matching any of its patterns: a path, a
+// directory above it, or a glob in which "*" also matches "/". An empty
+// pathspec matches everything.
+type pathspec []pathPattern
+
+type pathPattern struct {
+	path string
+	glob *regexp.Regexp
+}
+
+// newPathspec compiles pathspecs relative to prefix, the directory of the
+// repository they were given in.
+func newPathspec(specs []string, prefix string) pathspec {
+	var spec pathspec
+	for _, s := range specs {
+		p := path.Clean(path.Join(strings.ReplaceAll(prefix, "\\", "/"), s))
+		if p == "." {
+			return nil
+		}
+		pattern := pathPattern{path: p}
+		if strings.ContainsAny(p, "*?[") {
+			pattern.glob = compileGlob(p)
+		}
+		spec = append(spec, pattern)
+	}
+	return spec
+}
+
+// compileGlob translates a pathspec glob into a regular expression.
+func compileGlob(glob string) *regexp.Regexp {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return regexp.MustCompile("^" + regexp.QuoteMeta(glob) + "$")
+	}
+	return compiled
+}
+
+// matches reports whether the file at filePath matches the pathspec.
+func (s pathspec) matches(filePath string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, pattern := range s {
+		if filePath == pattern.path || strings.HasPrefix(filePath, pattern.path+"/") {
+			return true
+		}
+		if pattern.glob != nil && pattern.glob.MatchString(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayMatchUnder reports whether files under the directory dir can match
+// the pathspec.
+func (s pathspec) mayMatchUnder(dir string) bool {
+	if len(s) == 0 || s.matches(dir) {
+		return true
+	}
+	for _, pattern := range s {
+		if pattern.glob != nil || strings.HasPrefix(pattern.path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// renameThreshold is the similarity, in percent, above which a deleted
+	// and an added file are taken as a rename, as in git's default.
+	renameThreshold = 50
+
+	// renameLimit caps the candidate pairs compared for inexact renames,
+	// like git's diff.renameLimit.
+	renameLimit = 1000 * 1000
+
+	// spanSize is the longest chunk of content compared when estimating
+	// similarity; chunks otherwise end at newlines.
+	spanSize = 64
+)
+
+// detectRenames pairs deleted files with added files of identical or
+// similar content, like git's default rename detection.
+func (r *repository) detectRenames(changes []fileChange) ([]fileChange, error) {
+	var added, deleted []int
+	for i, change := range changes {
+		switch {
+		case change.added():
+			added = append(added, i)
+		case change.deleted():
+			deleted = append(deleted, i)
+		}
+	}
+	if len(added) == 0 || len(deleted) == 0 {
+		return changes, nil
+	}
+
+	renamedFrom := make(map[int]int)
+	used := make(map[int]bool)
+
+	// Exact renames first: the same content under another name.
+	for _, a := range added {
+		for _, d := range deleted {
+			if !used[d] && changes[d].oldHash == changes[a].newHash {
+				renamedFrom[a], used[d] = d, true
+				break
+			}
+		}
+	}
+
+	// Then the most similar remaining pairs above the threshold.
+	type candidate struct{ added, deleted, score int }
+	var candidates []candidate
+	var remainingAdded, remainingDeleted []int
+	for _, a := range added {
+		if _, ok := renamedFrom[a]; !ok && isRegularFile(changes[a].newMode) {
+			remainingAdded = append(remainingAdded, a)
+		}
+	}
+	for _, d := range deleted {
+		if !used[d] && isRegularFile(changes[d].oldMode) {
+			remainingDeleted = append(remainingDeleted, d)
+		}
+	}
+	if len(remainingAdded)*len(remainingDeleted) <= renameLimit {
+		spans := make(map[string]map[string]int)
+		contentSpans := func(hash string) (map[string]int, int, error) {
+			if s, ok := spans[hash]; ok {
+				return s, spanTotal(s), nil
+			}
+			data, err := r.typedObject(hash, objectBlob)
+			if err != nil {
+				return nil, 0, err
+			}
+			spans[hash] = countSpans(data)
+			return spans[hash], len(data), nil
+		}
+
+		for _, a := range remainingAdded {
+			dst, dstSize, err := contentSpans(changes[a].newHash)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range remainingDeleted {
+				src, srcSize, err := contentSpans(changes[d].oldHash)
+				if err != nil {
+					return nil, err
+				}
+				if score, ok := similarity(src, dst, srcSize, dstSize); ok {
+					candidates = append(candidates, candidate{added: a, deleted: d, score: score})
+				}
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	for _, c := range candidates {
+		if _, ok := renamedFrom[c.added]; ok || used[c.deleted] {
+			continue
+		}
+		renamedFrom[c.added], used[c.deleted] = c.deleted, true
+	}
+
+	result := make([]fileChange, 0, len(changes)-len(renamedFrom))
+	for i, change := range changes {
+		if used[i] {
+			continue
+		}
+		if d, ok := renamedFrom[i]; ok {
+			change.oldPath = changes[d].oldPath
+			change.oldHash = changes[d].oldHash
+			change.oldMode = changes[d].oldMode
+		}
+		result = append(result, change)
+	}
+	return result, nil
+}
+
+// isRegularFile reports whether a tree entry mode is a regular file.
+func isRegularFile(mode uint32) bool {
+	return mode&0o170000 == 0o100000
+}
+
+// countSpans splits content into chunks ending at a newline or after
+// spanSize bytes and counts the bytes of each distinct chunk.
+func countSpans(data []byte) map[string]int {
+	spans := make(map[string]int)
+	for len(data) > 0 {
+		n := bytes.IndexByte(data, '\n') + 1
+		if n == 0 || n > spanSize {
+			n = spanSize
+		}
+		if n > len(data) {
+			n = len(data)
+		}
+		spans[string(data[:n])] += n
+		data = data[n:]
+	}
+	return spans
+}
+
+// spanTotal returns the number of bytes counted in spans.
+func spanTotal(spans map[string]int) int {
+	total := 0
+	for _, n := range spans {
+		total += n
+	}
+	return total
+}
+
+// similarity returns the percentage of the larger file's content shared
+// with the other, and whether it reaches renameThreshold.
+func similarity(src, dst map[string]int, srcSize, dstSize int) (int, bool) {
+	larger, smaller := srcSize, dstSize
+	if smaller > larger {
+		larger, smaller = smaller, larger
+	}
+	if smaller == 0 || (larger-smaller)*100 > larger*(100-renameThreshold) {
+		return 0, false
+	}
+
+	shared := 0
+	for span, n := range src {
+		if m := dst[span]; m < n {
+			shared += m
+		} else {
+			shared += n
+		}
+	}
+	score := shared * 100 / larger
+	return score, score >= renameThreshold
+}
+
+const (
+	// binaryCheckSize is how much of a file is searched for a NUL byte to
+	// decide whether it is binary, as git does.
+	binaryCheckSize = 8000
+)
+
+// countLineChanges returns the lines added and deleted between two versions
+// of a file, or binary if either version is binary.
+func countLineChanges(before, after []byte) (added, deleted int, binary bool) {
+	if isBinary(before) || isBinary(after) {
+		return 0, 0, true
+	}
+
+	deletedLines, addedLines := diffLines(lineIDs(before, after))
+	for _, changed := range addedLines {
+		if changed {
+			added++
+		}
+	}
+	for _, changed := range deletedLines {
+		if changed {
+			deleted++
+		}
+	}
+	return added, deleted, false
+}
+
+// isBinary reports whether content looks binary.
+func isBinary(data []byte) bool {
+	if len(data) > binaryCheckSize {
+		data = data[:binaryCheckSize]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// lineIDs splits both versions into lines, keeping line endings, and
+// numbers each distinct line.
+func lineIDs(before, after []byte) ([]int, []int) {
+	ids := make(map[string]int)
+	split := func(data []byte) []int {
+		var lines []int
+		for len(data) > 0 {
+			n := bytes.IndexByte(data, '\n') + 1
+			if n == 0 {
+				n = len(data)
+			}
+			line := string(data[:n])
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			lines = append(lines, id)
+			data = data[n:]
+		}
+		return lines
+	}
+	return split(before), split(after)
+}
+
+// diffStat adds the diff statistics of the changes matching spec between
+// two trees to the commit, like git log --numstat.
+func (r *repository) diffStat(commit *Commit, oldTree, newTree string, spec pathspec) error {
+	changes, err := r.diffTrees(oldTree, newTree, spec)
+	if err != nil {
+		return err
+	}
+	if changes, err = r.detectRenames(changes); err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		before, err := r.fileContent(change.oldHash, change.oldMode)
+		if err != nil {
+			return err
+		}
+		after, err := r.fileContent(change.newHash, change.newMode)
+		if err != nil {
+			return err
+		}
+
+		added, deleted, _ := countLineChanges(before, after)
+		commit.Insertions += added
+		commit.Deletions += deleted
+		commit.FilesChanged++
+		commit.Files = append(commit.Files, change.path())
+	}
+	return nil
+}
+
+// fileContent returns the content of a file in a tree. A submodule is
+// shown as the commit it points to, as in git diff.
+func (r *repository) fileContent(hash string, mode uint32) ([]byte, error) {
+	switch {
+	case hash == "":
+		return nil, nil
+	case mode == modeGitlink:
+		return []byte("Subproject commit " + hash + "\n"), nil
+	}
+	return r.typedObject(hash, objectBlob)
+}
@@ -0,0 +1,164 @@
+package git
+
+import "container/heap"
+
+// Graph is the commit graph of a history, with every commit placed in a
+// lane like a column of git log --graph.
+type Graph struct {
+	// Nodes holds one node per commit, in the order of the commits.
+	Nodes []Node
+
+	// Lanes is the number of lanes in use at the widest point.
+	Lanes int
+}
+
+// Node is a commit's place in the graph. Parents and Children are indexes
+// of other commits of the history; parents outside it are left out.
+type Node struct {
+	Lane     int
+	Parents  []int
+	Children []int
+}
+
+// IsMerge reports whether the commit joins more than one parent of the
+// history.
+func (n Node) IsMerge() bool {
+	return len(n.Parents) > 1
+}
+
+// IsFork reports whether more than one commit of the history builds on the
+// commit, starting a branch.
+func (n Node) IsFork() bool {
+	return len(n.Children) > 1
+}
+
+// NewGraph builds the graph of commits given oldest first. Lanes are
+// assigned newest first, as git log --graph draws them: a commit takes the
+// leftmost lane waiting for it, or a new lane at the right; its first parent
+// continues the lane and further parents open lanes beside it. Lanes that
+// reach a commit already waited for elsewhere end there, and the lanes to
+// their right move left.
+//
+// Commits are ordered by date, and a skewed clock can place a parent after
+// one of its children, so lanes are assigned in topological order: every
+// commit after all of its children, otherwise newest first.
+func NewGraph(commits []Commit) *Graph {
+	index := make(map[string]int, len(commits))
+	for i, commit := range commits {
+		index[commit.Hash] = i
+	}
+
+	graph := &Graph{Nodes: make([]Node, len(commits))}
+	for i, commit := range commits {
+		for _, parent := range commit.Parents {
+			p, ok := index[parent]
+			if !ok {
+				continue
+			}
+			graph.Nodes[i].Parents = append(graph.Nodes[i].Parents, p)
+			graph.Nodes[p].Children = append(graph.Nodes[p].Children, i)
+		}
+	}
+
+	// columns holds the commit each lane is waiting for.
+	var columns []int
+	for _, i := range graph.topoOrder() {
+		lane := -1
+		kept := columns[:0]
+		for _, waiting := range columns {
+			if waiting == i {
+				if lane >= 0 {
+					continue
+				}
+				lane = len(kept)
+			}
+			kept = append(kept, waiting)
+		}
+		columns = kept
+		if lane < 0 {
+			lane = len(columns)
+			columns = append(columns, i)
+		}
+		graph.Nodes[i].Lane = lane
+
+		var next []int
+		for _, parent := range graph.Nodes[i].Parents {
+			if !containsIndex(columns, parent) && !containsIndex(next, parent) {
+				next = append(next, parent)
+			}
+		}
+		columns = append(columns[:lane], append(next, columns[lane+1:]...)...)
+
+		if len(columns) > graph.Lanes {
+			graph.Lanes = len(columns)
+		}
+		if lane >= graph.Lanes {
+			graph.Lanes = lane + 1
+		}
+	}
+
+	return graph
+}
+
+// topoOrder returns the indexes of the nodes with every node after all of
+// its children, taking the newest node first whenever several are ready. In
+// a history where parents are older than their children it is simply the
+// nodes newest first.
+func (g *Graph) topoOrder() []int {
+	waiting := make([]int, len(g.Nodes))
+	ready := &indexHeap{}
+	for i, node := range g.Nodes {
+		waiting[i] = len(node.Children)
+		if waiting[i] == 0 {
+			heap.Push(ready, i)
+		}
+	}
+
+	order := make([]int, 0, len(g.Nodes))
+	for ready.Len() > 0 {
+		i := heap.Pop(ready).(int)
+		order = append(order, i)
+		for _, parent := range g.Nodes[i].Parents {
+			if waiting[parent]--; waiting[parent] == 0 {
+				heap.Push(ready, parent)
+			}
+		}
+	}
+	return order
+}
+
+// indexHeap is a max-heap of node indexes.
+type indexHeap []int
+
+func (h indexHeap) Len() int {
+	return len(h)
+}
+
+func (h indexHeap) Less(i, j int) bool {
+	return h[i] > h[j]
+}
+
+func (h indexHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *indexHeap) Push(x any) {
+	*h = append(*h, x.(int))
+}
+
+func (h *indexHeap) Pop() any {
+	last := len(*h) - 1
+	i := (*h)[last]
+	*h = (*h)[:last]
+	return i
+}
+
+// containsIndex reports whether indexes contains i.
+func containsIndex(indexes []int, i int) bool {
+	for _, index := range indexes {
+		if index == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewGraph(t *testing.T) {
+	// a - b - c ----- e - f
+	//      \         /
+	//       d ------
+	commits := []Commit{
+		{Hash: "a", Parents: []string{"root"}},
+		{Hash: "b", Parents: []string{"a"}},
+		{Hash: "c", Parents: []string{"b"}},
+		{Hash: "d", Parents: []string{"b"}},
+		{Hash: "e", Parents: []string{"c", "d"}},
+		{Hash: "f", Parents: []string{"e"}},
+	}
+
+	graph := NewGraph(commits)
+	if graph.Lanes != 2 {
+		t.Errorf("lanes: got %d, want 2", graph.Lanes)
+	}
+
+	var lanes []int
+	for _, node := range graph.Nodes {
+		lanes = append(lanes, node.Lane)
+	}
+	if want := []int{0, 0, 0, 1, 0, 0}; !reflect.DeepEqual(lanes, want) {
+		t.Errorf("lanes: got %v, want %v", lanes, want)
+	}
+
+	if graph.Nodes[0].Parents != nil {
+		t.Errorf("root parents: got %v, want none in the history", graph.Nodes[0].Parents)
+	}
+	if b := graph.Nodes[1]; !b.IsFork() || !reflect.DeepEqual(b.Children, []int{2, 3}) {
+		t.Errorf("fork: got children %v, want [2 3]", b.Children)
+	}
+	if e := graph.Nodes[4]; !e.IsMerge() || !reflect.DeepEqual(e.Parents, []int{2, 3}) {
+		t.Errorf("merge: got parents %v, want [2 3]", e.Parents)
+	}
+	if graph.Nodes[5].IsMerge() || graph.Nodes[5].IsFork() {
+		t.Error("head: got a merge or fork")
+	}
+}
+
+// TestGraphLanes compares lanes with the columns git log --graph draws.
+func TestGraphLanes(t *testing.T) {
+	f := historyFixture(t)
+
+	log, err := ParseLogWithOptions(f.dir, LogOptions{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := NewGraph(log.Commits)
+
+	want := make(map[string]int)
+	for _, line := range strings.Split(f.git("log", "--all", "--graph", "--format=%H"), "\n") {
+		if star := strings.Index(line, "*"); star >= 0 {
+			want[strings.TrimSpace(line[star+1:])] = star / 2
+		}
+	}
+
+	for i, commit := range log.Commits {
+		if lane := graph.Nodes[i].Lane; lane != want[commit.Hash] {
+			t.Errorf("%s %q: got lane %d, want %d", commit.Hash[:7], commit.Message, lane, want[commit.Hash])
+		}
+	}
+}
+
+// TestGraphSkewedDates checks lanes when a clock running behind puts a
+// branch commit before its parent in date order.
+func TestGraphSkewedDates(t *testing.T) {
+	f := newFixture(t)
+	f.commit(ann, "Base", nil)
+	f.git("checkout", "-q", "-b", "side")
+	f.clock -= 2 * 3600
+	f.commit(bob, "Side, committed with a clock behind", nil)
+	f.git("checkout", "-q", "main")
+	f.clock += 2 * 3600
+	f.commit(ann, "Main", nil)
+	f.clock += 3600
+	f.git("merge", "-q", "--no-ff", "-m", "Merge branch 'side'", "side")
+
+	want := make(map[string]int)
+	for _, line := range strings.Split(f.git("log", "--graph", "--format=%H"), "\n") {
+		if star := strings.Index(line, "*"); star >= 0 {
+			want[strings.TrimSpace(line[star+1:])] = star / 2
+		}
+	}
+
+	exec, native := readBoth(t, f.dir, LogOptions{})
+	for _, log := range []*Log{exec, native} {
+		var messages []string
+		for _, commit := range log.Commits {
+			messages = append(messages, commit.Message)
+		}
+		if messages[0] != "Side, committed with a clock behind" || messages[1] != "Base" {
+			t.Fatalf("log order: got %q, want the side commit before its parent", messages)
+		}
+
+		graph := NewGraph(log.Commits)
+		if graph.Lanes != 2 {
+			t.Errorf("lanes: got %d, want 2", graph.Lanes)
+		}
+		for i, commit := range log.Commits {
+			if lane := graph.Nodes[i].Lane; lane != want[commit.Hash] {
+				t.Errorf("%q: got lane %d, want %d", commit.Message, lane, want[commit.Hash])
+			}
+		}
+	}
+}
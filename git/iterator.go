@@ -0,0 +1,211 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CommitIterator yields commits one at a time.
+type CommitIterator interface {
+	// Next returns the next commit, or io.EOF after the last one.
+	Next() (Commit, error)
+	// Close releases the iterator's resources. It is safe to call before
+	// the iterator is exhausted and more than once.
+	Close() error
+}
+
+// LogIterator streams commits from a running git log process, parsing each
+// record as it arrives, so memory use does not grow with the history.
+type LogIterator struct {
+	cmd     *exec.Cmd
+	stdout  io.ReadCloser
+	stderr  bytes.Buffer
+	reader  *bufio.Reader
+	filter  *authorFilter
+	aliases Aliases
+	skipped int
+	done    bool
+	closed  bool
+	cleanup func()
+}
+
+// OpenLog starts reading the Git log of the commits selected by opts from a
+// repository path or URL, which is cloned to a temporary directory first.
+// Commits are yielded newest first, or oldest first with opts.Reverse. The
+// iterator must be closed.
+func OpenLog(repoPath string, opts LogOptions) (*LogIterator, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	filter, err := opts.authorFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	isURL, err := isGitURL(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository path: %w", err)
+	}
+
+	actualPath := repoPath
+	cleanup := func() {}
+
+	if isURL {
+		fmt.Printf("Cloning repository from URL: %s\n", repoPath)
+		tempDir, err := os.MkdirTemp("", "git2midi-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		cleanup = func() { os.RemoveAll(tempDir) }
+
+		cloneCmd := exec.Command("git", "clone", repoPath, tempDir)
+		cloneCmd.Stderr = os.Stderr
+		if err := cloneCmd.Run(); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to clone repository: %w", err)
+		}
+
+		actualPath = tempDir
+	}
+
+	// Paths are only quoted for control characters, quotes and backslashes.
+	args := []string{"-C", actualPath, "-c", "core.quotePath=false", "log", "--numstat", "--pretty=format:" + logFormat}
+	it := &LogIterator{
+		cmd:     exec.Command("git", append(args, opts.args()...)...),
+		filter:  filter,
+		aliases: opts.Aliases,
+		cleanup: cleanup,
+	}
+	it.cmd.Stderr = &it.stderr
+
+	it.stdout, err = it.cmd.StdoutPipe()
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	if err := it.cmd.Start(); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to run git: %w", err)
+	}
+	it.reader = bufio.NewReader(it.stdout)
+
+	return it, nil
+}
+
+// Next returns the next commit, or io.EOF after the last one. Records that
+// cannot be parsed are skipped and counted; commits excluded by the author
+// patterns are skipped silently.
+func (it *LogIterator) Next() (Commit, error) {
+	for !it.done {
+		record, err := it.reader.ReadString(recordMarker[0])
+		if err == io.EOF {
+			it.done = true
+		} else if err != nil {
+			return Commit{}, err
+		}
+
+		record = strings.TrimSuffix(record, recordMarker)
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+
+		commit, err := parseRecord(record)
+		if err != nil {
+			it.skipped++
+			continue
+		}
+		it.aliases.apply(&commit)
+		if it.filter.match(&commit) {
+			return commit, nil
+		}
+	}
+
+	if err := it.wait(); err != nil {
+		return Commit{}, err
+	}
+	return Commit{}, io.EOF
+}
+
+// Skipped returns the number of records so far that could not be parsed as
+// commits.
+func (it *LogIterator) Skipped() int {
+	return it.skipped
+}
+
+// Close stops git if it is still running and removes any temporary clone.
+func (it *LogIterator) Close() error {
+	if it.closed {
+		return nil
+	}
+	if !it.done {
+		it.cmd.Process.Kill()
+	}
+	it.wait()
+	return nil
+}
+
+// wait waits for git to exit, once, and reports a failure with its error
+// output.
+func (it *LogIterator) wait() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	defer it.cleanup()
+
+	err := it.cmd.Wait()
+	if err == nil || !it.done {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && it.stderr.Len() > 0 {
+		return errors.New(strings.TrimSpace(it.stderr.String()))
+	}
+	return fmt.Errorf("failed to read git log: %w", err)
+}
+
+// limitIterator stops after a number of commits.
+type limitIterator struct {
+	it        CommitIterator
+	remaining int
+}
+
+// Limit returns an iterator yielding at most count commits of it. Closing
+// it closes it, which stops reading the rest of the history.
+func Limit(it CommitIterator, count int) CommitIterator {
+	return &limitIterator{it: it, remaining: count}
+}
+
+func (l *limitIterator) Next() (Commit, error) {
+	if l.remaining <= 0 {
+		return Commit{}, io.EOF
+	}
+	l.remaining--
+	return l.it.Next()
+}
+
+func (l *limitIterator) Close() error {
+	return l.it.Close()
+}
+
+// Collect reads all remaining commits of an iterator into a slice.
+func Collect(it CommitIterator) ([]Commit, error) {
+	var commits []Commit
+	for {
+		commit, err := it.Next()
+		if err == io.EOF {
+			return commits, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+}
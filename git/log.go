@@ -1,104 +1,211 @@
 package git
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"time"
 )
 
 // ParseLog parses Git log output from the specified repository path or URL.
 // If repoPath is a URL, it will be cloned to a temporary directory first.
 // Returns a slice of commits in chronological order (oldest first).
+// Author names and emails are mapped through the repository's .mailmap.
+// Records that cannot be parsed are dropped; use ParseLogWithOptions to
+// count them.
 func ParseLog(repoPath string) ([]Commit, error) {
-	isURL, err := isGitURL(repoPath)
+	log, err := ParseLogWithOptions(repoPath, LogOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("invalid repository path: %w", err)
+		return nil, err
 	}
+	return log.Commits, nil
+}
 
-	var actualPath string
-	var tempDir string
-	var needsCleanup bool
+// Log is the result of reading a repository's history.
+type Log struct {
+	// Commits in chronological order (oldest first).
+	Commits []Commit
 
-	if isURL {
-		fmt.Printf("Cloning repository from URL: %s\n", repoPath)
-		tempDir, err = os.MkdirTemp("", "git2midi-*")
-		if err != nil {
-			return nil, fmt.Errorf("failed to create temporary directory: %w", err)
-		}
-		needsCleanup = true
+	// Skipped counts the log records that could not be parsed as commits.
+	Skipped int
+}
 
-		cloneCmd := exec.Command("git", "clone", repoPath, tempDir)
-		cloneCmd.Stderr = os.Stderr
-		if err := cloneCmd.Run(); err != nil {
-			os.RemoveAll(tempDir)
-			return nil, fmt.Errorf("failed to clone repository: %w", err)
-		}
+// ParseLogWithOptions parses the Git log of the commits selected by opts,
+// like ParseLog.
+func ParseLogWithOptions(repoPath string, opts LogOptions) (*Log, error) {
+	return ReadLog(Exec{}, repoPath, opts)
+}
 
-		actualPath = tempDir
-	} else {
-		actualPath = repoPath
-	}
+// Fields of a log record, in logFormat order.
+const (
+	fieldHash = iota
+	fieldParents
+	fieldAuthor
+	fieldAuthorEmail
+	fieldAuthorTime
+	fieldCommitter
+	fieldCommitterEmail
+	fieldCommitTime
+	fieldRefs
+	fieldCoAuthors
+	fieldSubject
+	fieldBody
+	fieldCount
+)
 
-	defer func() {
-		if needsCleanup && tempDir != "" {
-			os.RemoveAll(tempDir)
-		}
-	}()
+const (
+	// recordMarker starts each commit record in the log output. Fields are
+	// NUL-terminated, since commit messages cannot contain NUL, and the
+	// numstat lines follow the last field.
+	recordMarker = "\x1e"
+
+	// coAuthorTrailers formats the values of a commit's Co-authored-by
+	// trailers on one line, separated by unitSeparator.
+	coAuthorTrailers = "%(trailers:key=Co-authored-by,valueonly,separator=%x1f)"
+	unitSeparator    = "\x1f"
+)
+
+// logFormat is the git log pretty format producing one record per commit.
+var logFormat = "%x1e" + strings.Join([]string{
+	"%H", "%P",
+	"%aN", "%aE", "%at",
+	"%cN", "%cE", "%ct",
+	"%D", coAuthorTrailers,
+	"%s", "%b",
+}, "%x00") + "%x00"
 
-	cmd := exec.Command("git", "-C", actualPath, "log", "--pretty=format:%H|%ct|%an|%s")
-	output, err := cmd.Output()
+// parseRecord parses one commit record of logFormat output, without its
+// leading recordMarker.
+func parseRecord(record string) (Commit, error) {
+	fields := strings.SplitN(record, "\x00", fieldCount+1)
+	if len(fields) != fieldCount+1 {
+		return Commit{}, fmt.Errorf("log record has %d fields, want %d", len(fields)-1, fieldCount)
+	}
+
+	authorTime, err := parseUnixTimestamp(fields[fieldAuthorTime])
+	if err != nil {
+		return Commit{}, fmt.Errorf("invalid author time %q", fields[fieldAuthorTime])
+	}
+	commitTime, err := parseUnixTimestamp(fields[fieldCommitTime])
 	if err != nil {
-		return nil, fmt.Errorf("failed to read git log: %w", err)
+		return Commit{}, fmt.Errorf("invalid commit time %q", fields[fieldCommitTime])
 	}
 
-	var commits []Commit
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	commit := Commit{
+		Hash:            strings.TrimSpace(fields[fieldHash]),
+		Parents:         strings.Fields(fields[fieldParents]),
+		Timestamp:       commitTime,
+		Author:          fields[fieldAuthor],
+		Email:           fields[fieldAuthorEmail],
+		AuthorTimestamp: authorTime,
+		Committer:       fields[fieldCommitter],
+		CommitterEmail:  fields[fieldCommitterEmail],
+		Message:         fields[fieldSubject],
+		Body:            strings.TrimSpace(fields[fieldBody]),
+	}
+	parseRefs(&commit, fields[fieldRefs])
+	parseCoAuthors(&commit, fields[fieldCoAuthors])
+	parseConventional(&commit)
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+	if err := commit.Validate(); err != nil {
+		return Commit{}, err
+	}
 
-		parts := strings.SplitN(line, "|", 4)
-		if len(parts) != 4 {
-			continue
-		}
+	for _, line := range strings.Split(fields[fieldCount], "\n") {
+		parseNumstat(&commit, line)
+	}
 
-		var timestamp int64
-		if ts, err := parseUnixTimestamp(parts[1]); err == nil {
-			timestamp = ts
-		} else {
-			timestamp = time.Now().Unix()
-		}
+	return commit, nil
+}
 
-		commit := Commit{
-			Hash:      parts[0],
-			Timestamp: timestamp,
-			Author:    parts[2],
-			Message:   parts[3],
+// parseNumstat adds one "insertions<TAB>deletions<TAB>path" line to the commit's
+// diff statistics. Binary files report "-" for both counts and only count as
+// a changed file.
+func parseNumstat(commit *Commit, line string) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return
+	}
+
+	if n, err := strconv.Atoi(parts[0]); err == nil {
+		commit.Insertions += n
+	}
+	if n, err := strconv.Atoi(parts[1]); err == nil {
+		commit.Deletions += n
+	}
+
+	commit.FilesChanged++
+	commit.Files = append(commit.Files, renamedPath(unquotePath(parts[2])))
+}
+
+// parseRefs adds the refs of a %D decoration ("HEAD -> main, tag: v1.0,
+// origin/main") to the commit's tags and branches. HEAD itself, symbolic
+// remote HEADs and the "grafted" mark of shallow clones are skipped.
+func parseRefs(commit *Commit, decoration string) {
+	if decoration == "" {
+		return
+	}
+
+	for _, ref := range strings.Split(decoration, ", ") {
+		ref = strings.TrimSpace(ref)
+		switch {
+		case strings.HasPrefix(ref, "tag: "):
+			commit.Tags = append(commit.Tags, strings.TrimPrefix(ref, "tag: "))
+		case strings.HasPrefix(ref, "HEAD -> "):
+			commit.Branches = append(commit.Branches, strings.TrimPrefix(ref, "HEAD -> "))
+		case ref == "HEAD" || ref == "" || strings.HasSuffix(ref, "/HEAD"):
+			// Detached or symbolic HEAD, not a branch of its own.
+		case ref == "grafted":
+			// The parents of a shallow commit were cut off by the clone.
+		default:
+			commit.Branches = append(commit.Branches, ref)
 		}
+	}
+}
 
-		if err := commit.Validate(); err != nil {
-			continue
+// parseCoAuthors adds the identities of unitSeparator-separated
+// Co-authored-by trailer values to the commit.
+func parseCoAuthors(commit *Commit, trailers string) {
+	for _, value := range strings.Split(trailers, unitSeparator) {
+		if identity := ParseIdentity(value); identity.Name != "" {
+			commit.CoAuthors = append(commit.CoAuthors, identity)
 		}
+	}
+}
 
-		commits = append(commits, commit)
+// unquotePath undoes the C-style quoting git applies to paths with unusual
+// characters.
+func unquotePath(path string) string {
+	if !strings.HasPrefix(path, "\"") {
+		return path
+	}
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
 	}
+	return path
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// renamedPath resolves numstat rename notation ("old => new" or
+// "dir/{old => new}/file") to the new path.
+func renamedPath(path string) string {
+	if !strings.Contains(path, " => ") {
+		return path
 	}
 
-	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
-		commits[i], commits[j] = commits[j], commits[i]
+	openBrace := strings.Index(path, "{")
+	closeBrace := strings.LastIndex(path, "}")
+	if openBrace >= 0 && closeBrace > openBrace {
+		inner := path[openBrace+1 : closeBrace]
+		arrow := strings.Index(inner, " => ")
+		if arrow < 0 {
+			return path
+		}
+		resolved := path[:openBrace] + inner[arrow+4:] + path[closeBrace+1:]
+		return strings.Replace(resolved, "//", "/", 1)
 	}
 
-	return commits, nil
+	return path[strings.Index(path, " => ")+4:]
 }
 
 // parseUnixTimestamp parses a Unix timestamp string into an int64.
@@ -132,3 +239,19 @@ func isGitURL(path string) (bool, error) {
 
 	return false, nil
 }
+
+// RepoName returns a human-readable name for a repository path or URL: the
+// last path element with any ".git" suffix removed.
+func RepoName(repoPath string) string {
+	name := strings.TrimRight(repoPath, "/\\")
+	if isURL, _ := isGitURL(repoPath); !isURL {
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+	}
+
+	if i := strings.LastIndexAny(name, "/\\:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, ".git")
+}
@@ -0,0 +1,347 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixture is a throwaway repository with deterministic authors and dates.
+type fixture struct {
+	t     *testing.T
+	dir   string
+	clock int64
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	f := &fixture{t: t, dir: t.TempDir(), clock: 1700000000}
+	f.git("init", "-q")
+	f.git("symbolic-ref", "HEAD", "refs/heads/main")
+	return f
+}
+
+// git runs a git command in the repository and returns its output.
+func (f *fixture) git(args ...string) string {
+	f.t.Helper()
+	return f.gitAs(Identity{Name: "Committer", Email: "committer@example.com"}, args...)
+}
+
+// gitAs runs a git command with author set to the given identity.
+func (f *fixture) gitAs(author Identity, args ...string) string {
+	f.t.Helper()
+	date := fmt.Sprintf("%d +0000", f.clock)
+	cmd := exec.Command("git", append([]string{"-C", f.dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"HOME="+f.dir,
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME="+author.Name,
+		"GIT_AUTHOR_EMAIL="+author.Email,
+		"GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_NAME=Committer",
+		"GIT_COMMITTER_EMAIL=committer@example.com",
+		"GIT_COMMITTER_DATE="+date,
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		f.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// commit writes the files and commits them as author, an hour after the
+// previous commit.
+func (f *fixture) commit(author Identity, message string, files map[string]string) {
+	f.t.Helper()
+	for name, content := range files {
+		path := filepath.Join(f.dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			f.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+	f.clock += 3600
+	f.gitAs(author, "add", "-A")
+	f.gitAs(author, "commit", "-q", "--allow-empty", "-m", message)
+}
+
+var (
+	ann   = Identity{Name: "Ann | Smith", Email: "ann@example.com"}
+	bob   = Identity{Name: "bob", Email: "bob@old.example.com"}
+	carol = Identity{Name: "Carol", Email: "carol@example.com"}
+)
+
+func TestParseLog(t *testing.T) {
+	f := newFixture(t)
+	f.commit(ann, "Add parser\n\nFirst line of the body.\nSecond line.", map[string]string{
+		"parser.go": "a\nb\nc\n",
+	})
+	f.commit(bob, "Pair on lexer\n\nCo-authored-by: Carol <carol@example.com>", map[string]string{
+		"lexer/lexer.go": "x\n",
+		".mailmap":       "Bob Jones <bob@example.com> bob <bob@old.example.com>\n",
+	})
+	f.git("tag", "v1.0")
+	f.git("checkout", "-q", "-b", "feature", "HEAD~1")
+	f.commit(carol, "Add feature", map[string]string{"feature.go": "f\n"})
+	f.git("checkout", "-q", "main")
+	f.clock += 3600
+	f.gitAs(ann, "merge", "-q", "--no-ff", "-m", "Merge branch 'feature'", "feature")
+
+	commits, err := ParseLog(f.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 4 {
+		t.Fatalf("got %d commits, want 4", len(commits))
+	}
+
+	first := commits[0]
+	if first.Author != ann.Name || first.Email != ann.Email {
+		t.Errorf("author: got %q <%s>, want %q <%s>", first.Author, first.Email, ann.Name, ann.Email)
+	}
+	if first.Committer != "Committer" || first.CommitterEmail != "committer@example.com" {
+		t.Errorf("committer: got %q <%s>", first.Committer, first.CommitterEmail)
+	}
+	if first.Timestamp != 1700003600 || first.AuthorTimestamp != 1700003600 {
+		t.Errorf("timestamps: got %d and %d, want 1700003600", first.Timestamp, first.AuthorTimestamp)
+	}
+	if first.Message != "Add parser" || first.Body != "First line of the body.\nSecond line." {
+		t.Errorf("message: got %q / %q", first.Message, first.Body)
+	}
+	if len(first.Parents) != 0 || first.Insertions != 3 || first.FilesChanged != 1 {
+		t.Errorf("root commit: got %d parents, %d insertions, %d files", len(first.Parents), first.Insertions, first.FilesChanged)
+	}
+
+	pair := commits[1]
+	if pair.Author != "Bob Jones" || pair.Email != "bob@example.com" {
+		t.Errorf("mailmap: got %q <%s>, want Bob Jones <bob@example.com>", pair.Author, pair.Email)
+	}
+	if len(pair.CoAuthors) != 1 || pair.CoAuthors[0] != carol {
+		t.Errorf("co-authors: got %v, want [%v]", pair.CoAuthors, carol)
+	}
+	if len(pair.Tags) != 1 || pair.Tags[0] != "v1.0" {
+		t.Errorf("tags: got %v, want [v1.0]", pair.Tags)
+	}
+	if len(pair.Parents) != 1 || pair.Parents[0] != first.Hash {
+		t.Errorf("parents: got %v, want [%s]", pair.Parents, first.Hash)
+	}
+
+	merge := commits[3]
+	if !merge.IsMerge() || len(merge.Parents) != 2 {
+		t.Errorf("merge: got %d parents", len(merge.Parents))
+	}
+	if len(merge.Branches) != 1 || merge.Branches[0] != "main" {
+		t.Errorf("branches: got %v, want [main]", merge.Branches)
+	}
+}
+
+func TestParseLogWithOptions(t *testing.T) {
+	f := newFixture(t)
+	f.commit(ann, "Add API", map[string]string{"services/api/main.go": "api\n"})
+	f.commit(bob, "Add web", map[string]string{"services/web/main.go": "web\n"})
+	f.git("tag", "v1.0")
+	f.commit(ann, "Fix API", map[string]string{"services/api/main.go": "api v2\n", "README": "docs\n"})
+	f.commit(carol, "Fix web", map[string]string{"services/web/main.go": "web v2\n"})
+
+	tests := []struct {
+		name string
+		opts LogOptions
+		want []string
+	}{
+		{name: "all", want: []string{"Add API", "Add web", "Fix API", "Fix web"}},
+		{name: "range", opts: LogOptions{Revision: "v1.0..main"}, want: []string{"Fix API", "Fix web"}},
+		{name: "path", opts: LogOptions{Paths: []string{"services/api/"}}, want: []string{"Add API", "Fix API"}},
+		{name: "exclude author", opts: LogOptions{ExcludeAuthor: "^Ann"}, want: []string{"Add web", "Fix web"}},
+		{name: "since", opts: LogOptions{Since: "@1700010000"}, want: []string{"Fix API", "Fix web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, err := ParseLogWithOptions(f.dir, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, commit := range log.Commits {
+				got = append(got, commit.Message)
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	log, err := ParseLogWithOptions(f.dir, LogOptions{Paths: []string{"services/api/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fix := log.Commits[1]; fix.FilesChanged != 1 || fix.Files[0] != "services/api/main.go" {
+		t.Errorf("path-limited stats: got %d files %v", fix.FilesChanged, fix.Files)
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	hash := strings.Repeat("a", 40)
+	valid := strings.Join([]string{
+		hash, "", "Ann", "ann@example.com", "1700000000",
+		"Ann", "ann@example.com", "1700000100",
+		"tag: v1.0", "", "Subject | with pipe", "Body",
+	}, "\x00") + "\x00\n\n2\t1\tmain.go\n-\t-\tlogo.png\n"
+
+	commit, err := parseRecord(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Message != "Subject | with pipe" || commit.Insertions != 2 || commit.Deletions != 1 || commit.FilesChanged != 2 {
+		t.Errorf("got %+v", commit)
+	}
+
+	invalid := []string{
+		"truncated\x00record",
+		strings.Replace(valid, "1700000100", "yesterday", 1),
+		strings.Replace(valid, hash, "abc", 1),
+	}
+	for _, record := range invalid {
+		if _, err := parseRecord(record); err == nil {
+			t.Errorf("expected an error for record %q", record)
+		}
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	var commit Commit
+	for _, line := range []string{
+		"12\t3\tmain.go",
+		"-\t-\tlogo.png",
+		"4\t4\tsrc/{lexer.go => scanner.go}",
+		"0\t0\tdocs/guide.md => guide.md",
+		"1\t0\t\"caf\\303\\251.txt\"",
+		"not a numstat line",
+		"",
+	} {
+		parseNumstat(&commit, line)
+	}
+
+	if commit.Insertions != 17 || commit.Deletions != 7 {
+		t.Errorf("got +%d -%d, want +17 -7", commit.Insertions, commit.Deletions)
+	}
+	if commit.FilesChanged != 5 {
+		t.Errorf("got %d files changed, want 5 (binary files count)", commit.FilesChanged)
+	}
+	want := []string{"main.go", "logo.png", "src/scanner.go", "guide.md", "café.txt"}
+	if strings.Join(commit.Files, ",") != strings.Join(want, ",") {
+		t.Errorf("got files %q, want %q", commit.Files, want)
+	}
+}
+
+func TestRenamedPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "main.go", want: "main.go"},
+		{in: "old.go => new.go", want: "new.go"},
+		{in: "docs/guide.md => guide.md", want: "guide.md"},
+		{in: "src/{lexer.go => scanner.go}", want: "src/scanner.go"},
+		{in: "src/{old => new}/file.go", want: "src/new/file.go"},
+		{in: "src/{ => nested}/file.go", want: "src/nested/file.go"},
+		{in: "src/{nested => }/file.go", want: "src/file.go"},
+	}
+
+	for _, tt := range tests {
+		if got := renamedPath(tt.in); got != tt.want {
+			t.Errorf("renamedPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUnquotePath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "plain.txt", want: "plain.txt"},
+		{in: `"tab\there.txt"`, want: "tab\there.txt"},
+		{in: `"quote\"d.txt"`, want: `quote"d.txt`},
+		{in: `"caf\303\251.txt"`, want: "café.txt"},
+		{in: `"unterminated`, want: `"unterminated`},
+	}
+
+	for _, tt := range tests {
+		if got := unquotePath(tt.in); got != tt.want {
+			t.Errorf("unquotePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestOpenLog(t *testing.T) {
+	f := newFixture(t)
+	for i := 1; i <= 5; i++ {
+		f.commit(ann, fmt.Sprintf("Commit %d", i), map[string]string{"file": fmt.Sprint(i)})
+	}
+
+	tests := []struct {
+		name    string
+		reverse bool
+		limit   int
+		want    []string
+	}{
+		{name: "newest first", want: []string{"Commit 5", "Commit 4", "Commit 3", "Commit 2", "Commit 1"}},
+		{name: "reverse", reverse: true, want: []string{"Commit 1", "Commit 2", "Commit 3", "Commit 4", "Commit 5"}},
+		{name: "limit", reverse: true, limit: 2, want: []string{"Commit 1", "Commit 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, err := OpenLog(f.dir, LogOptions{Reverse: tt.reverse})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var it CommitIterator = log
+			if tt.limit > 0 {
+				it = Limit(log, tt.limit)
+			}
+			defer it.Close()
+
+			commits, err := Collect(it)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, commit := range commits {
+				got = append(got, commit.Message)
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if err := it.Close(); err != nil {
+				t.Errorf("close: %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenLogError(t *testing.T) {
+	f := newFixture(t)
+	f.commit(ann, "Initial commit", nil)
+
+	it, err := OpenLog(f.dir, LogOptions{Revision: "no-such-branch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	if _, err := it.Next(); err == nil || !strings.Contains(err.Error(), "no-such-branch") {
+		t.Errorf("got %v, want an error naming the revision", err)
+	}
+}
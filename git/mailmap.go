@@ -0,0 +1,98 @@
+package git
+
+import (
+	"strings"
+)
+
+// mailmap maps the identities recorded in commits to canonical ones, read
+// from a .mailmap file. Entries are keyed by lower-cased commit email.
+type mailmap map[string]*mailmapEntry
+
+// mailmapEntry replaces the identity of every commit with an email, unless
+// an entry for the commit's name as well is more specific.
+type mailmapEntry struct {
+	Identity
+	byName map[string]Identity
+}
+
+// parseMailmap parses .mailmap lines in any of git's forms:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func parseMailmap(content string) mailmap {
+	m := make(mailmap)
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		proper, rest, ok := parseMailmapIdentity(line)
+		if !ok {
+			continue
+		}
+		old, _, ok := parseMailmapIdentity(rest)
+		if !ok {
+			// A single identity fixes the name of everyone with its email.
+			old, proper = Identity{Email: proper.Email}, Identity{Name: proper.Name}
+		}
+
+		key := strings.ToLower(old.Email)
+		entry := m[key]
+		if entry == nil {
+			entry = &mailmapEntry{byName: make(map[string]Identity)}
+			m[key] = entry
+		}
+		if old.Name == "" {
+			if proper.Name != "" {
+				entry.Name = proper.Name
+			}
+			if proper.Email != "" {
+				entry.Email = proper.Email
+			}
+		} else {
+			entry.byName[strings.ToLower(old.Name)] = proper
+		}
+	}
+	return m
+}
+
+// parseMailmapIdentity parses "Name <email>" at the start of s, where the
+// name may be empty, and returns the rest of s.
+func parseMailmapIdentity(s string) (Identity, string, bool) {
+	open := strings.Index(s, "<")
+	if open < 0 {
+		return Identity{}, "", false
+	}
+	end := strings.Index(s[open:], ">")
+	if end < 0 {
+		return Identity{}, "", false
+	}
+	end += open
+
+	return Identity{
+		Name:  strings.TrimSpace(s[:open]),
+		Email: strings.TrimSpace(s[open+1 : end]),
+	}, s[end+1:], true
+}
+
+// lookup returns the canonical name and email of a commit identity.
+func (m mailmap) lookup(id Identity) Identity {
+	entry := m[strings.ToLower(id.Email)]
+	if entry == nil {
+		return id
+	}
+
+	replacement, ok := entry.byName[strings.ToLower(id.Name)]
+	if !ok {
+		replacement = entry.Identity
+	}
+	if replacement.Name != "" {
+		id.Name = replacement.Name
+	}
+	if replacement.Email != "" {
+		id.Email = replacement.Email
+	}
+	return id
+}
@@ -0,0 +1,664 @@
+package git
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Native reads history directly from the .git directory: loose objects,
+// packfiles and refs, without the git binary. It produces the same commits
+// as Exec for the options it supports, but cannot clone URLs.
+type Native struct{}
+
+// Name returns "native".
+func (Native) Name() string {
+	return "native"
+}
+
+// Available always returns nil: the native backend needs no executables.
+func (Native) Available() error {
+	return nil
+}
+
+// OpenLog starts walking the history of a local repository.
+func (Native) OpenLog(repoPath string, opts LogOptions) (LogReader, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	filter, err := opts.authorFilter()
+	if err != nil {
+		return nil, err
+	}
+	if isURL, _ := isGitURL(repoPath); isURL {
+		return nil, fmt.Errorf("the native backend cannot clone %s; use the git backend", repoPath)
+	}
+
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &nativeLog{
+		repo:    repo,
+		filter:  filter,
+		aliases: opts.Aliases,
+		spec:    newPathspec(opts.Paths, repo.prefix),
+		queued:  make(map[string]bool),
+	}
+	if err := log.start(opts); err != nil {
+		repo.close()
+		return nil, err
+	}
+	return log, nil
+}
+
+// nativeLog walks a repository's history like git log: newest commit date
+// first, skipping excluded commits and, with a pathspec, commits that do
+// not change matching files.
+type nativeLog struct {
+	repo    *repository
+	filter  *authorFilter
+	aliases Aliases
+	spec    pathspec
+	mailmap mailmap
+	refs    map[string]*decoration
+
+	// since and until bound commit dates when set.
+	since, until *int64
+
+	queue    commitQueue
+	queued   map[string]bool
+	excluded map[string]bool
+
+	// reverse buffers the walk to yield it oldest first.
+	reverse  bool
+	buffered []*commitObject
+	walked   bool
+
+	skipped int
+	closed  bool
+}
+
+// decoration holds the refs pointing at a commit.
+type decoration struct {
+	tags     []string
+	branches []string
+}
+
+// start resolves the revisions, dates and refs of the walk and queues its
+// starting commits.
+func (l *nativeLog) start(opts LogOptions) error {
+	l.reverse = opts.Reverse
+
+	now := time.Now()
+	for _, bound := range []struct {
+		value string
+		date  **int64
+	}{{opts.Since, &l.since}, {opts.Until, &l.until}} {
+		if bound.value == "" {
+			continue
+		}
+		date, err := parseDate(bound.value, now)
+		if err != nil {
+			return err
+		}
+		*bound.date = &date
+	}
+
+	if content, err := l.repo.readWorkTreeFile(".mailmap"); err == nil {
+		l.mailmap = parseMailmap(string(content))
+	}
+
+	refs, err := l.repo.refs()
+	if err != nil {
+		return err
+	}
+	if err := l.decorate(refs); err != nil {
+		return err
+	}
+
+	include, exclude, err := l.revisions(opts, refs)
+	if err != nil {
+		return err
+	}
+	if l.excluded, err = l.ancestors(exclude...); err != nil {
+		return err
+	}
+	for _, hash := range include {
+		if err := l.push(hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revisions returns the commits the walk starts from and the commits whose
+// history is excluded. A revision is a commit, "A..B" for the commits of B
+// not in A, "A...B" for the commits in either but not both, or "^A".
+func (l *nativeLog) revisions(opts LogOptions, refs map[string]string) (include, exclude []string, err error) {
+	if opts.All {
+		names := make([]string, 0, len(refs))
+		for name := range refs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		names = append(names, "HEAD")
+
+		for _, name := range names {
+			hash, err := l.repo.ref(name)
+			if err != nil {
+				continue
+			}
+			if hash, err = l.repo.peelToCommit(hash); err == nil {
+				include = append(include, hash)
+			}
+		}
+		return include, nil, nil
+	}
+
+	revision := opts.Revision
+	resolve := func(rev string) (string, error) {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		return l.repo.resolve(rev)
+	}
+
+	if a, b, ok := strings.Cut(revision, "..."); ok {
+		if a, err = resolve(a); err != nil {
+			return nil, nil, err
+		}
+		if b, err = resolve(b); err != nil {
+			return nil, nil, err
+		}
+		// Commits reachable from both sides are the history of their
+		// merge bases.
+		fromA, err := l.ancestors(a)
+		if err != nil {
+			return nil, nil, err
+		}
+		fromB, err := l.ancestors(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		for hash := range fromA {
+			if fromB[hash] {
+				exclude = append(exclude, hash)
+			}
+		}
+		return []string{a, b}, exclude, nil
+	}
+
+	if a, b, ok := strings.Cut(revision, ".."); ok {
+		if a, err = resolve(a); err != nil {
+			return nil, nil, err
+		}
+		if b, err = resolve(b); err != nil {
+			return nil, nil, err
+		}
+		return []string{b}, []string{a}, nil
+	}
+
+	if negated, ok := strings.CutPrefix(revision, "^"); ok {
+		hash, err := resolve(negated)
+		return nil, []string{hash}, err
+	}
+
+	hash, err := resolve(revision)
+	return []string{hash}, nil, err
+}
+
+// ancestors returns the commits reachable from the given ones, themselves
+// included.
+func (l *nativeLog) ancestors(hashes ...string) (map[string]bool, error) {
+	reachable := make(map[string]bool)
+	pending := append([]string(nil), hashes...)
+	for len(pending) > 0 {
+		hash := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if reachable[hash] {
+			continue
+		}
+		reachable[hash] = true
+
+		commit, err := l.repo.readCommit(hash)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, commit.parents...)
+	}
+	return reachable, nil
+}
+
+// decorate records the branches and tags pointing at each commit, as shown
+// by git log --decorate: local and remote-tracking branches, the stash, and
+// tags peeled to the commits they name.
+func (l *nativeLog) decorate(refs map[string]string) error {
+	head := strings.TrimPrefix(l.repo.symbolicRef("HEAD"), "refs/heads/")
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	// Local branches sort before remote-tracking ones, and the current
+	// branch comes first.
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "refs/heads/"+head) != (names[j] == "refs/heads/"+head) {
+			return names[i] == "refs/heads/"+head
+		}
+		return names[i] < names[j]
+	})
+
+	l.refs = make(map[string]*decoration)
+	for _, name := range names {
+		hash, typ, err := l.repo.peel(refs[name])
+		if errors.Is(err, errObjectNotFound) || (err == nil && typ != objectCommit) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		d := l.refs[hash]
+		if d == nil {
+			d = &decoration{}
+			l.refs[hash] = d
+		}
+		switch {
+		case strings.HasPrefix(name, "refs/tags/"):
+			d.tags = append(d.tags, strings.TrimPrefix(name, "refs/tags/"))
+		case strings.HasPrefix(name, "refs/heads/"):
+			d.branches = append(d.branches, strings.TrimPrefix(name, "refs/heads/"))
+		case strings.HasPrefix(name, "refs/remotes/") && !strings.HasSuffix(name, "/HEAD"):
+			d.branches = append(d.branches, strings.TrimPrefix(name, "refs/remotes/"))
+		case name == "refs/stash":
+			d.branches = append(d.branches, name)
+		}
+	}
+	return nil
+}
+
+// push queues a commit of the walk unless it was queued before or is
+// excluded. Commits that cannot be parsed are skipped and counted.
+func (l *nativeLog) push(hash string) error {
+	if l.queued[hash] || l.excluded[hash] {
+		return nil
+	}
+	l.queued[hash] = true
+
+	commit, err := l.repo.readCommit(hash)
+	if errors.Is(err, errObjectNotFound) {
+		return err
+	}
+	if err != nil {
+		l.skipped++
+		return nil
+	}
+	heap.Push(&l.queue, commit)
+	return nil
+}
+
+// walk returns the next commit of the walk, newest first, or nil at the end.
+func (l *nativeLog) walk() (*commitObject, error) {
+	for l.queue.Len() > 0 {
+		commit := heap.Pop(&l.queue).(*commitObject)
+		date := commit.committer.time
+		if l.since != nil && date < *l.since {
+			// Older history is cut off.
+			continue
+		}
+
+		parents, show, err := l.simplify(commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			if err := l.push(parent); err != nil {
+				return nil, err
+			}
+		}
+
+		if show && (l.until == nil || date <= *l.until) {
+			return commit, nil
+		}
+	}
+	return nil, nil
+}
+
+// simplify decides, with a pathspec, whether a commit changes matching
+// files and which parents to follow. A commit with the same matching files
+// as one of its parents is hidden, and only that parent is followed, as in
+// git's default history simplification.
+func (l *nativeLog) simplify(commit *commitObject) ([]string, bool, error) {
+	if len(l.spec) == 0 {
+		return commit.parents, true, nil
+	}
+
+	if len(commit.parents) == 0 {
+		changes, err := l.repo.diffTrees("", commit.tree, l.spec)
+		return nil, len(changes) > 0, err
+	}
+
+	for _, hash := range commit.parents {
+		parent, err := l.repo.readCommit(hash)
+		if err != nil {
+			return nil, false, err
+		}
+		changes, err := l.repo.diffTrees(parent.tree, commit.tree, l.spec)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(changes) == 0 {
+			return []string{hash}, false, nil
+		}
+	}
+	return commit.parents, true, nil
+}
+
+// Next returns the next commit, or io.EOF after the last one.
+func (l *nativeLog) Next() (Commit, error) {
+	for {
+		object, err := l.nextObject()
+		if err != nil {
+			return Commit{}, err
+		}
+		if object == nil {
+			return Commit{}, io.EOF
+		}
+
+		commit, err := l.commit(object)
+		if err != nil {
+			return Commit{}, err
+		}
+		l.aliases.apply(&commit)
+		if l.filter.match(&commit) {
+			return commit, nil
+		}
+	}
+}
+
+// nextObject returns the next commit object in the requested order.
+func (l *nativeLog) nextObject() (*commitObject, error) {
+	if l.closed {
+		return nil, nil
+	}
+	if !l.reverse {
+		return l.walk()
+	}
+
+	if !l.walked {
+		for {
+			object, err := l.walk()
+			if err != nil {
+				return nil, err
+			}
+			if object == nil {
+				break
+			}
+			l.buffered = append(l.buffered, object)
+		}
+		l.walked = true
+	}
+
+	if len(l.buffered) == 0 {
+		return nil, nil
+	}
+	object := l.buffered[len(l.buffered)-1]
+	l.buffered = l.buffered[:len(l.buffered)-1]
+	return object, nil
+}
+
+// commit builds the Commit of a commit object as the git backend would
+// parse it from git log: identities mapped through .mailmap, subject and
+// body, co-authors, Conventional Commits type, refs and diff statistics
+// against the first parent.
+func (l *nativeLog) commit(object *commitObject) (Commit, error) {
+	author := l.mailmap.lookup(object.author.Identity)
+	committer := l.mailmap.lookup(object.committer.Identity)
+	subject, body := splitMessage(object.message)
+
+	commit := Commit{
+		Hash:            object.hash,
+		Parents:         object.parents,
+		Timestamp:       object.committer.time,
+		Author:          author.Name,
+		Email:           author.Email,
+		AuthorTimestamp: object.author.time,
+		Committer:       committer.Name,
+		CommitterEmail:  committer.Email,
+		Message:         subject,
+		Body:            strings.TrimSpace(body),
+		CoAuthors:       coAuthors(object.message),
+	}
+	parseConventional(&commit)
+	if d := l.refs[object.hash]; d != nil {
+		commit.Tags = d.tags
+		commit.Branches = d.branches
+	}
+
+	// Like git log --numstat, merges get no statistics and root commits
+	// are compared with the empty tree.
+	if len(object.parents) <= 1 {
+		parentTree := ""
+		if len(object.parents) == 1 {
+			parent, err := l.repo.readCommit(object.parents[0])
+			if err != nil {
+				return Commit{}, err
+			}
+			parentTree = parent.tree
+		}
+		if err := l.repo.diffStat(&commit, parentTree, object.tree, l.spec); err != nil {
+			return Commit{}, err
+		}
+	}
+	return commit, nil
+}
+
+// Skipped returns the number of commits so far that could not be parsed.
+func (l *nativeLog) Skipped() int {
+	return l.skipped
+}
+
+// Close releases the repository's packfiles.
+func (l *nativeLog) Close() error {
+	if !l.closed {
+		l.closed = true
+		l.repo.close()
+	}
+	return nil
+}
+
+// commitQueue orders commits newest first by commit date, and in the order
+// they were queued among equal dates.
+type commitQueue struct {
+	commits []*commitObject
+	order   []int
+	count   int
+}
+
+func (q *commitQueue) Len() int {
+	return len(q.commits)
+}
+
+func (q *commitQueue) Less(i, j int) bool {
+	if q.commits[i].committer.time != q.commits[j].committer.time {
+		return q.commits[i].committer.time > q.commits[j].committer.time
+	}
+	return q.order[i] < q.order[j]
+}
+
+func (q *commitQueue) Swap(i, j int) {
+	q.commits[i], q.commits[j] = q.commits[j], q.commits[i]
+	q.order[i], q.order[j] = q.order[j], q.order[i]
+}
+
+func (q *commitQueue) Push(x any) {
+	q.commits = append(q.commits, x.(*commitObject))
+	q.order = append(q.order, q.count)
+	q.count++
+}
+
+func (q *commitQueue) Pop() any {
+	last := len(q.commits) - 1
+	commit := q.commits[last]
+	q.commits, q.order = q.commits[:last], q.order[:last]
+	return commit
+}
+
+// splitMessage splits a commit message like git's %s and %b: the subject
+// is the first paragraph with its lines joined by spaces, the body the rest.
+func splitMessage(message string) (string, string) {
+	lines := strings.Split(message, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	var subject []string
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) != "" {
+		subject = append(subject, strings.TrimRight(lines[0], " \t\r"))
+		lines = lines[1:]
+	}
+	return strings.Join(subject, " "), strings.Join(lines, "\n")
+}
+
+var (
+	// trailerLine matches a "Token: value" trailer.
+	trailerLine = regexp.MustCompile(`^([A-Za-z0-9-]+)\s*:\s*(.*)$`)
+
+	// generatedTrailers are added by git itself; one of them lets a block
+	// mixing trailers with other lines count as trailers.
+	generatedTrailers = []string{"Signed-off-by: ", "(cherry picked from commit "}
+)
+
+// coAuthors returns the identities of a message's Co-authored-by trailers.
+// Trailers are the last paragraph of the body, if it consists of trailers,
+// or at least a quarter of it does and git generated one of them.
+func coAuthors(message string) []Identity {
+	_, body := splitMessage(message)
+	lines := strings.Split(body, "\n")
+
+	// A "---" line starts a patch, which is not part of the message.
+	for i, line := range lines {
+		if strings.HasPrefix(line, "---") {
+			lines = lines[:i]
+			break
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	start := len(lines)
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	block := lines[start:]
+
+	var trailers []string
+	others, generated := 0, false
+	for _, line := range block {
+		switch {
+		case (line[0] == ' ' || line[0] == '\t') && len(trailers) > 0:
+			// A continuation of the previous trailer's value.
+			trailers[len(trailers)-1] += " " + strings.TrimSpace(line)
+		case trailerLine.MatchString(line):
+			trailers = append(trailers, line)
+		default:
+			others++
+		}
+		for _, prefix := range generatedTrailers {
+			if strings.HasPrefix(line, prefix) {
+				generated = true
+			}
+		}
+	}
+	if len(trailers) == 0 || (others > 0 && !(generated && len(trailers)*3 >= others)) {
+		return nil
+	}
+
+	var identities []Identity
+	for _, trailer := range trailers {
+		match := trailerLine.FindStringSubmatch(trailer)
+		if !strings.EqualFold(match[1], "Co-authored-by") {
+			continue
+		}
+		if identity := ParseIdentity(match[2]); identity.Name != "" {
+			identities = append(identities, identity)
+		}
+	}
+	return identities
+}
+
+// dateLayouts are the absolute date formats accepted for date windows,
+// in local time unless they carry a zone.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	time.RFC3339,
+	"2006/01/02",
+	"2006.01.02",
+	time.RFC1123Z,
+	"Mon Jan 2 15:04:05 2006 -0700",
+}
+
+// relativeDate matches dates such as "6 months ago" or "2.weeks.ago".
+var relativeDate = regexp.MustCompile(`^(\d+) (second|minute|hour|day|week|month|year)s?( ago)?$`)
+
+// parseDate parses the date formats git accepts most often: "@<unix
+// time>", absolute dates with an optional time, relative dates such as
+// "3 weeks ago", "yesterday" and "now". A date without a time of day means
+// midnight.
+func parseDate(s string, now time.Time) (int64, error) {
+	s = strings.TrimSpace(s)
+	if unix, ok := strings.CutPrefix(s, "@"); ok {
+		seconds, err := strconv.ParseInt(unix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid date: %s", s)
+		}
+		return seconds, nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t.Unix(), nil
+		}
+	}
+
+	relative := strings.ToLower(strings.NewReplacer(".", " ", "_", " ").Replace(s))
+	switch relative {
+	case "now":
+		return now.Unix(), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1).Unix(), nil
+	}
+
+	match := relativeDate.FindStringSubmatch(strings.Join(strings.Fields(relative), " "))
+	if match == nil {
+		return 0, fmt.Errorf("unsupported date: %s", s)
+	}
+	n, _ := strconv.Atoi(match[1])
+	switch match[2] {
+	case "second":
+		return now.Add(-time.Duration(n) * time.Second).Unix(), nil
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute).Unix(), nil
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour).Unix(), nil
+	case "day":
+		return now.AddDate(0, 0, -n).Unix(), nil
+	case "week":
+		return now.AddDate(0, 0, -7*n).Unix(), nil
+	case "month":
+		return now.AddDate(0, -n, 0).Unix(), nil
+	}
+	return now.AddDate(-n, 0, 0).Unix(), nil
+}
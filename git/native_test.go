@@ -0,0 +1,374 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// historyFixture builds a repository exercising what git log reports:
+// nested files, renames, binary files, mode changes, a .mailmap, trailers,
+// tags, branches and a merge.
+func historyFixture(t *testing.T) *fixture {
+	f := newFixture(t)
+
+	lines := func(n int, prefix string) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			fmt.Fprintf(&b, "%s line %d\n", prefix, i)
+		}
+		return b.String()
+	}
+
+	f.commit(ann, "Add parser\nacross two lines\n\nBody of the first commit.", map[string]string{
+		"src/parser.go":  lines(20, "parse"),
+		"src/lexer.go":   lines(10, "lex"),
+		"docs/guide.md":  "# Guide\n",
+		"logo.png":       "\x89PNG\x00\x01\x02",
+		"no-newline.txt": "last line",
+	})
+	f.commit(bob, "Pair on lexer\n\nCo-authored-by: Carol <carol@example.com>\nSigned-off-by: bob <bob@old.example.com>", map[string]string{
+		"src/lexer.go":   strings.Replace(lines(10, "lex"), "lex line 5", "lexer line 5", 1) + "lex line 11\n",
+		".mailmap":       "Bob Jones <bob@example.com> bob <bob@old.example.com>\n",
+		"no-newline.txt": "last line\n",
+	})
+	f.git("tag", "-a", "v1.0", "-m", "Release 1.0")
+
+	f.git("mv", "src/parser.go", "src/grammar.go")
+	f.git("mv", "docs/guide.md", "guide.md")
+	f.commit(carol, "Move files", map[string]string{
+		"src/grammar.go": lines(20, "parse") + "parse line 21\n",
+		"logo.png":       "\x89PNG\x00\x01\x03",
+	})
+	f.git("update-index", "--chmod=+x", "src/lexer.go")
+	f.commit(ann, "Make lexer executable", nil)
+	f.git("tag", "light")
+
+	f.git("checkout", "-q", "-b", "feature", "v1.0")
+	f.commit(carol, "Add feature", map[string]string{"feature/feature.go": "f\n"})
+	if err := os.Remove(filepath.Join(f.dir, "no-newline.txt")); err != nil {
+		t.Fatal(err)
+	}
+	f.commit(carol, "Remove text file\n\nNot a trailer: this paragraph has prose.\nCo-authored-by: Nobody <nobody@example.com>", nil)
+	f.git("checkout", "-q", "main")
+	f.clock += 3600
+	f.gitAs(ann, "merge", "-q", "--no-ff", "-m", "Merge branch 'feature'", "feature")
+	f.commit(bob, "Touch lexer", map[string]string{"src/lexer.go": "rewritten\n"})
+	return f
+}
+
+// readBoth reads the fixture with both backends, failing the test if
+// either fails.
+func readBoth(t *testing.T, dir string, opts LogOptions) (*Log, *Log) {
+	t.Helper()
+	exec, err := ReadLog(Exec{}, dir, opts)
+	if err != nil {
+		t.Fatalf("git backend: %v", err)
+	}
+	native, err := ReadLog(Native{}, dir, opts)
+	if err != nil {
+		t.Fatalf("native backend: %v", err)
+	}
+	return exec, native
+}
+
+// normalize makes commits from both backends comparable: ref order is not
+// significant and empty slices equal nil ones.
+func normalize(commits []Commit) []Commit {
+	for i := range commits {
+		c := &commits[i]
+		sort.Strings(c.Tags)
+		sort.Strings(c.Branches)
+		if len(c.Parents) == 0 {
+			c.Parents = nil
+		}
+	}
+	return commits
+}
+
+func compareLogs(t *testing.T, exec, native *Log) {
+	t.Helper()
+	want, got := normalize(exec.Commits), normalize(native.Commits)
+	if len(got) != len(want) {
+		t.Fatalf("got %d commits, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("commit %d:\n got %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+	if native.Skipped != 0 {
+		t.Errorf("skipped %d commits", native.Skipped)
+	}
+}
+
+func TestNativeBackend(t *testing.T) {
+	f := historyFixture(t)
+
+	tests := []struct {
+		name string
+		opts LogOptions
+	}{
+		{name: "head"},
+		{name: "all", opts: LogOptions{All: true}},
+		{name: "range", opts: LogOptions{Revision: "v1.0..main"}},
+		{name: "symmetric range", opts: LogOptions{Revision: "light...feature"}},
+		{name: "ancestor", opts: LogOptions{Revision: "main~2"}},
+		{name: "path", opts: LogOptions{Paths: []string{"src/"}}},
+		{name: "glob", opts: LogOptions{Paths: []string{"*.go"}}},
+		{name: "since", opts: LogOptions{Since: "@1700010000"}},
+		{name: "until", opts: LogOptions{Until: "@1700010000"}},
+		{name: "author", opts: LogOptions{Author: "Jones"}},
+	}
+
+	// Compare loose objects first, then the same history packed with deltas.
+	for _, layout := range []string{"loose", "packed"} {
+		if layout == "packed" {
+			f.git("gc", "-q", "--aggressive", "--prune=now")
+		}
+		for _, tt := range tests {
+			t.Run(layout+"/"+tt.name, func(t *testing.T) {
+				exec, native := readBoth(t, f.dir, tt.opts)
+				if len(exec.Commits) == 0 {
+					t.Fatal("no commits read")
+				}
+				compareLogs(t, exec, native)
+			})
+		}
+	}
+}
+
+func TestNativeBackendLayouts(t *testing.T) {
+	f := historyFixture(t)
+
+	t.Run("subdirectory", func(t *testing.T) {
+		opts := LogOptions{Paths: []string{"lexer.go"}}
+		exec, native := readBoth(t, filepath.Join(f.dir, "src"), opts)
+		compareLogs(t, exec, native)
+	})
+
+	t.Run("bare clone", func(t *testing.T) {
+		bare := filepath.Join(t.TempDir(), "bare.git")
+		f.git("clone", "-q", "--bare", f.dir, bare)
+		exec, native := readBoth(t, bare, LogOptions{All: true})
+		compareLogs(t, exec, native)
+	})
+
+	t.Run("shallow clone", func(t *testing.T) {
+		shallow := filepath.Join(t.TempDir(), "shallow")
+		f.git("clone", "-q", "--depth=2", "file://"+f.dir, shallow)
+		exec, native := readBoth(t, shallow, LogOptions{})
+		compareLogs(t, exec, native)
+	})
+}
+
+func TestNativeBackendErrors(t *testing.T) {
+	f := newFixture(t)
+	f.commit(ann, "Initial commit", nil)
+
+	if _, err := (Native{}).OpenLog(f.dir, LogOptions{Revision: "no-such-branch"}); err == nil || !strings.Contains(err.Error(), "no-such-branch") {
+		t.Errorf("got %v, want an error naming the revision", err)
+	}
+	if _, err := (Native{}).OpenLog(t.TempDir(), LogOptions{}); err == nil {
+		t.Error("expected an error outside a repository")
+	}
+	if _, err := (Native{}).OpenLog("https://example.com/repo.git", LogOptions{}); err == nil {
+		t.Error("expected an error for a URL")
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("the quick brown fox")
+	delta := []byte{
+		19, 24, // base and result sizes
+		0x80 | 0x10 | 0x01, 4, 6, // copy 6 bytes from offset 4: "quick "
+		4, 's', 'l', 'o', 'w', // insert "slow"
+		0x80 | 0x10 | 0x01, 9, 10, // copy " brown fox"
+		4, '!', '!', '!', '!', // insert "!!!!"
+	}
+
+	got, err := applyDelta(base, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "quick slow brown fox!!!!" {
+		t.Errorf("got %q", got)
+	}
+
+	for _, corrupt := range [][]byte{
+		{18, 1, 4, 'x'},                // wrong base size
+		{19, 1, 0x91, 30, 1},           // copy past the end of the base
+		{19, 5, 4, 'a', 'b'},           // truncated insert
+		{19, 1, 0},                     // reserved instruction
+		{19, 9, 0x90, 3, 1, 'x'},       // result size mismatch
+		{19},                           // truncated result size
+		{19, 0x80},                     // result size without its last byte
+		{19, 2, 0x91, 0, 6},            // copy past the result size
+		{19, 1, 4, 'a', 'b', 'c', 'd'}, // insert past the result size
+		// A result size of 2^62 with a one-byte body.
+		{19, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40, 1, 'x'},
+		// A result size overflowing an int64.
+		{19, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f, 1, 'x'},
+	} {
+		if _, err := applyDelta(base, corrupt); err == nil {
+			t.Errorf("expected an error for delta %v", corrupt)
+		}
+	}
+
+	if _, err := applyDelta([]byte("abc"), []byte{3}); err == nil {
+		t.Errorf("expected an error for a delta without a result size")
+	}
+}
+
+func TestParseIndexFanout(t *testing.T) {
+	fanout := func(counts map[int]uint32) []byte {
+		index := make([]byte, 256*4)
+		for i, count := range counts {
+			binary.BigEndian.PutUint32(index[i*4:], count)
+		}
+		return index
+	}
+
+	tests := []struct {
+		name    string
+		counts  map[int]uint32
+		wantErr bool
+	}{
+		{name: "empty", counts: map[int]uint32{}},
+		{name: "decreasing", counts: map[int]uint32{0: 5}, wantErr: true},
+		{name: "above the count", counts: map[int]uint32{10: 3, 255: 2}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&packfile{}).parseIndex(fanout(tt.counts))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelfReferencingDelta(t *testing.T) {
+	// A pack of one ref-delta whose base is the delta itself.
+	id := bytes.Repeat([]byte{0x11}, hashSize)
+	var delta bytes.Buffer
+	w := zlib.NewWriter(&delta)
+	w.Write([]byte{0, 0})
+	w.Close()
+
+	pack := append([]byte("PACK\x00\x00\x00\x02\x00\x00\x00\x01"), byte(objectRefDelta)<<4|2)
+	pack = append(append(pack, id...), delta.Bytes()...)
+
+	index := make([]byte, 256*4, 256*4+4+hashSize)
+	for i := int(id[0]); i < 256; i++ {
+		binary.BigEndian.PutUint32(index[i*4:], 1)
+	}
+	index = binary.BigEndian.AppendUint32(index, 12)
+	index = append(index, id...)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pack-test.pack")
+	if err := os.WriteFile(path, pack, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pack-test.idx"), index, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := openPack(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.file.Close()
+
+	r := &repository{packs: []*packfile{p}, objects: newObjectCache[string](4096)}
+	if _, _, err := r.object(hex.EncodeToString(id)); err == nil {
+		t.Error("expected an error for a delta based on itself")
+	}
+}
+
+func TestCountLineChanges(t *testing.T) {
+	tests := []struct {
+		before, after  string
+		added, deleted int
+		binary         bool
+	}{
+		{before: "", after: "a\nb\n", added: 2},
+		{before: "a\nb\nc\n", after: "a\nc\n", deleted: 1},
+		{before: "a\nb\nc\n", after: "a\nB\nc\nd\n", added: 2, deleted: 1},
+		{before: "a", after: "a\n", added: 1, deleted: 1},
+		{before: "a\x00", after: "b", binary: true},
+	}
+
+	for _, tt := range tests {
+		added, deleted, binary := countLineChanges([]byte(tt.before), []byte(tt.after))
+		if added != tt.added || deleted != tt.deleted || binary != tt.binary {
+			t.Errorf("%q -> %q: got +%d -%d binary=%v, want +%d -%d binary=%v",
+				tt.before, tt.after, added, deleted, binary, tt.added, tt.deleted, tt.binary)
+		}
+	}
+}
+
+func TestMailmap(t *testing.T) {
+	m := parseMailmap(`# Comment
+Ann Smith <ann@example.com>
+<bob@example.com> <bob@old.example.com>
+Carol <carol@example.com> <c@example.com>
+Dan <dan@example.com> Danny <shared@example.com>
+`)
+
+	tests := []struct {
+		in, want Identity
+	}{
+		{Identity{"ann", "ANN@example.com"}, Identity{"Ann Smith", "ANN@example.com"}},
+		{Identity{"Bob", "bob@old.example.com"}, Identity{"Bob", "bob@example.com"}},
+		{Identity{"c", "c@example.com"}, Identity{"Carol", "carol@example.com"}},
+		{Identity{"Danny", "shared@example.com"}, Identity{"Dan", "dan@example.com"}},
+		{Identity{"Other", "shared@example.com"}, Identity{"Other", "shared@example.com"}},
+	}
+	for _, tt := range tests {
+		if got := m.lookup(tt.in); got != tt.want {
+			t.Errorf("lookup(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"@1700000000", time.Unix(1700000000, 0)},
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+		{"2024-01-31 08:30", time.Date(2024, 1, 31, 8, 30, 0, 0, time.Local)},
+		{"2024-01-31T08:30:00Z", time.Date(2024, 1, 31, 8, 30, 0, 0, time.UTC)},
+		{"6 months ago", time.Date(2023, 9, 15, 12, 0, 0, 0, time.Local)},
+		{"2.weeks.ago", time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)},
+		{"yesterday", time.Date(2024, 3, 14, 12, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.in, now)
+		if err != nil {
+			t.Errorf("parseDate(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want.Unix() {
+			t.Errorf("parseDate(%q) = %v, want %v", tt.in, time.Unix(got, 0), tt.want)
+		}
+	}
+
+	if _, err := parseDate("next blue moon", now); err == nil {
+		t.Error("expected an error for an unsupported date")
+	}
+}
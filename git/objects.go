@@ -0,0 +1,344 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// objectType is the type of a Git object, numbered as in packfiles.
+type objectType int
+
+const (
+	objectCommit   objectType = 1
+	objectTree     objectType = 2
+	objectBlob     objectType = 3
+	objectTag      objectType = 4
+	objectOfsDelta objectType = 6
+	objectRefDelta objectType = 7
+)
+
+// objectTypes maps the type names of loose object headers to types.
+var objectTypes = map[string]objectType{
+	"commit": objectCommit,
+	"tree":   objectTree,
+	"blob":   objectBlob,
+	"tag":    objectTag,
+}
+
+func (t objectType) String() string {
+	for name, typ := range objectTypes {
+		if typ == t {
+			return name
+		}
+	}
+	return fmt.Sprintf("object type %d", int(t))
+}
+
+// hashSize is the length of a SHA-1 object name in bytes.
+const hashSize = 20
+
+// errObjectNotFound reports an object missing from the repository.
+var errObjectNotFound = errors.New("object not found")
+
+// object returns the type and content of the named object, looking in the
+// packfiles first and then among the loose objects.
+func (r *repository) object(hash string) (objectType, []byte, error) {
+	return r.chainedObject(hash, 0)
+}
+
+// chainedObject returns the named object as the base of depth deltas. Errors
+// name the object only at the top of a chain.
+func (r *repository) chainedObject(hash string, depth int) (objectType, []byte, error) {
+	if cached, ok := r.objects.get(hash); ok {
+		return cached.typ, cached.data, nil
+	}
+
+	id, err := hex.DecodeString(hash)
+	if err != nil || len(id) != hashSize {
+		return 0, nil, fmt.Errorf("invalid object name %q", hash)
+	}
+
+	for _, pack := range r.packs {
+		offset, ok := pack.find(id)
+		if !ok {
+			continue
+		}
+		typ, data, err := pack.object(r, offset, depth)
+		if err != nil && depth > 0 {
+			return 0, nil, err
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("object %s: %w", hash, err)
+		}
+		r.objects.put(hash, typ, data)
+		return typ, data, nil
+	}
+
+	typ, data, err := r.looseObject(hash)
+	if err != nil {
+		return 0, nil, err
+	}
+	r.objects.put(hash, typ, data)
+	return typ, data, nil
+}
+
+// typedObject returns the content of the named object, which must be of
+// type want.
+func (r *repository) typedObject(hash string, want objectType) ([]byte, error) {
+	typ, data, err := r.object(hash)
+	if err != nil {
+		return nil, err
+	}
+	if typ != want {
+		return nil, fmt.Errorf("object %s is a %s, not a %s", hash, typ, want)
+	}
+	return data, nil
+}
+
+// looseObject reads a zlib-compressed object from the objects directory.
+func (r *repository) looseObject(hash string) (objectType, []byte, error) {
+	file, err := os.Open(filepath.Join(r.objectsDir(), hash[:2], hash[2:]))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil, fmt.Errorf("%w: %s", errObjectNotFound, hash)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+
+	zr, err := zlib.NewReader(file)
+	if err != nil {
+		return 0, nil, fmt.Errorf("object %s: %w", hash, err)
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("object %s: %w", hash, err)
+	}
+
+	// The content follows a "<type> <size>\0" header.
+	nul := bytes.IndexByte(raw, 0)
+	if nul < 0 {
+		return 0, nil, fmt.Errorf("object %s: missing header", hash)
+	}
+	name, size, _ := strings.Cut(string(raw[:nul]), " ")
+	typ, ok := objectTypes[name]
+	if !ok {
+		return 0, nil, fmt.Errorf("object %s: unknown type %q", hash, name)
+	}
+	data := raw[nul+1:]
+	if n, err := strconv.Atoi(size); err != nil || n != len(data) {
+		return 0, nil, fmt.Errorf("object %s: size %q does not match content", hash, size)
+	}
+	return typ, data, nil
+}
+
+// commitObject is a parsed commit object.
+type commitObject struct {
+	hash    string
+	tree    string
+	parents []string
+
+	author    signature
+	committer signature
+
+	// message is the raw commit message: subject, blank line and body.
+	message string
+}
+
+// signature is the identity and time of an author or committer line.
+type signature struct {
+	Identity
+	time int64
+}
+
+// readCommit reads and parses the named commit.
+func (r *repository) readCommit(hash string) (*commitObject, error) {
+	data, err := r.typedObject(hash, objectCommit)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := parseCommitObject(hash, data)
+	if err != nil {
+		return nil, err
+	}
+	if r.shallow[hash] {
+		// The parents of a shallow commit were cut off by the clone.
+		commit.parents = nil
+	}
+	return commit, nil
+}
+
+// parseCommitObject parses the headers and message of a commit object.
+// Unknown headers, such as gpgsig, and their continuation lines are skipped.
+func parseCommitObject(hash string, data []byte) (*commitObject, error) {
+	headers, message, _ := strings.Cut(string(data), "\n\n")
+	commit := &commitObject{hash: hash, message: message}
+
+	for _, line := range strings.Split(headers, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		var err error
+		switch key {
+		case "tree":
+			commit.tree = value
+		case "parent":
+			commit.parents = append(commit.parents, value)
+		case "author":
+			commit.author, err = parseSignature(value)
+		case "committer":
+			commit.committer, err = parseSignature(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("commit %s: %w", hash, err)
+		}
+	}
+
+	if commit.tree == "" {
+		return nil, fmt.Errorf("commit %s: missing tree", hash)
+	}
+	return commit, nil
+}
+
+// parseSignature parses "Name <email> 1700000000 +0100".
+func parseSignature(s string) (signature, error) {
+	open := strings.LastIndex(s, "<")
+	end := strings.LastIndex(s, ">")
+	if open < 0 || end < open {
+		return signature{}, fmt.Errorf("invalid signature %q", s)
+	}
+
+	when := strings.Fields(s[end+1:])
+	if len(when) == 0 {
+		return signature{}, fmt.Errorf("signature %q has no time", s)
+	}
+	time, err := strconv.ParseInt(when[0], 10, 64)
+	if err != nil {
+		return signature{}, fmt.Errorf("invalid time in signature %q", s)
+	}
+
+	return signature{
+		Identity: Identity{
+			Name:  strings.TrimSpace(s[:open]),
+			Email: s[open+1 : end],
+		},
+		time: time,
+	}, nil
+}
+
+// treeEntry is one entry of a tree object.
+type treeEntry struct {
+	name string
+	mode uint32
+	hash string
+}
+
+// File modes of tree entries.
+const (
+	modeTree    = 0o40000
+	modeSymlink = 0o120000
+	modeGitlink = 0o160000
+)
+
+func (e treeEntry) isTree() bool {
+	return e.mode == modeTree
+}
+
+// readTree reads and parses the named tree. An empty hash is the empty tree.
+func (r *repository) readTree(hash string) ([]treeEntry, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	data, err := r.typedObject(hash, objectTree)
+	if err != nil {
+		return nil, err
+	}
+	return parseTree(hash, data)
+}
+
+// parseTree parses the "<mode> <name>\0<hash>" entries of a tree object.
+func parseTree(hash string, data []byte) ([]treeEntry, error) {
+	var entries []treeEntry
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if space < 0 || nul < space || len(data) < nul+1+hashSize {
+			return nil, fmt.Errorf("tree %s: malformed entry", hash)
+		}
+
+		mode, err := strconv.ParseUint(string(data[:space]), 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("tree %s: invalid mode %q", hash, data[:space])
+		}
+		entries = append(entries, treeEntry{
+			name: string(data[space+1 : nul]),
+			mode: uint32(mode),
+			hash: hex.EncodeToString(data[nul+1 : nul+1+hashSize]),
+		})
+		data = data[nul+1+hashSize:]
+	}
+	return entries, nil
+}
+
+// peel follows tag objects from the named object until it reaches a
+// non-tag object, and returns that object's name and type.
+func (r *repository) peel(hash string) (string, objectType, error) {
+	for {
+		typ, data, err := r.object(hash)
+		if err != nil {
+			return "", 0, err
+		}
+		if typ != objectTag {
+			return hash, typ, nil
+		}
+
+		target := ""
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "object "); ok {
+				target = value
+				break
+			}
+		}
+		if target == "" {
+			return "", 0, fmt.Errorf("tag %s has no object", hash)
+		}
+		hash = target
+	}
+}
+
+// objectCache keeps recently read objects. It is cleared when it fills up,
+// which is cheap and keeps the objects of nearby commits, whose trees and
+// delta bases are read over and over, at hand.
+type objectCache[K comparable] struct {
+	limit   int
+	entries map[K]cachedObject
+}
+
+type cachedObject struct {
+	typ  objectType
+	data []byte
+}
+
+func newObjectCache[K comparable](limit int) *objectCache[K] {
+	return &objectCache[K]{limit: limit, entries: make(map[K]cachedObject)}
+}
+
+func (c *objectCache[K]) get(key K) (cachedObject, bool) {
+	object, ok := c.entries[key]
+	return object, ok
+}
+
+func (c *objectCache[K]) put(key K, typ objectType, data []byte) {
+	if len(c.entries) >= c.limit {
+		c.entries = make(map[K]cachedObject)
+	}
+	c.entries[key] = cachedObject{typ: typ, data: data}
+}
@@ -0,0 +1,113 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LogOptions selects the commits read from a repository.
+type LogOptions struct {
+	// Revision is a revision or range as accepted by git log, such as a
+	// branch name or "v1.0..v2.0". Empty means HEAD.
+	Revision string
+
+	// All reads the history of every ref instead of Revision.
+	All bool
+
+	// Since and Until limit commits to a date window, in any format git
+	// accepts, such as "2024-01-31" or "6 months ago".
+	Since string
+	Until string
+
+	// Author and ExcludeAuthor are regular expressions matched against the
+	// author's name and email (after .mailmap and Aliases). A commit is kept
+	// if it matches Author, when set, and does not match ExcludeAuthor.
+	Author        string
+	ExcludeAuthor string
+
+	// Aliases, when set, give authors and co-authors their canonical names
+	// as commits are read.
+	Aliases Aliases
+
+	// Reverse lists commits oldest first, as git log --reverse.
+	Reverse bool
+
+	// Paths limits the history to commits touching these pathspecs, such
+	// as "services/api/". Diff statistics only count matching files.
+	Paths []string
+}
+
+// validate checks the options for mistakes git would report less clearly.
+func (o LogOptions) validate() error {
+	if o.All && o.Revision != "" {
+		return errors.New("a revision cannot be combined with all refs")
+	}
+	if strings.HasPrefix(o.Revision, "-") {
+		return fmt.Errorf("invalid revision: %s", o.Revision)
+	}
+	return nil
+}
+
+// args returns the git log arguments selecting the commits, ending with the
+// pathspecs after "--".
+func (o LogOptions) args() []string {
+	var args []string
+	if o.Since != "" {
+		args = append(args, "--since="+o.Since)
+	}
+	if o.Until != "" {
+		args = append(args, "--until="+o.Until)
+	}
+	if o.Reverse {
+		args = append(args, "--reverse")
+	}
+	if o.All {
+		args = append(args, "--all")
+	}
+	if o.Revision != "" {
+		args = append(args, o.Revision)
+	}
+	args = append(args, "--")
+	return append(args, o.Paths...)
+}
+
+// authorFilter keeps commits by author name or email.
+type authorFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// authorFilter compiles the author patterns of the options.
+func (o LogOptions) authorFilter() (*authorFilter, error) {
+	var filter authorFilter
+	var err error
+
+	if o.Author != "" {
+		if filter.include, err = regexp.Compile(o.Author); err != nil {
+			return nil, fmt.Errorf("invalid author pattern: %w", err)
+		}
+	}
+	if o.ExcludeAuthor != "" {
+		if filter.exclude, err = regexp.Compile(o.ExcludeAuthor); err != nil {
+			return nil, fmt.Errorf("invalid exclude author pattern: %w", err)
+		}
+	}
+	return &filter, nil
+}
+
+// match reports whether the filter keeps the commit.
+func (f *authorFilter) match(c *Commit) bool {
+	matches := func(re *regexp.Regexp) bool {
+		return re.MatchString(c.Author) || re.MatchString(c.Email)
+	}
+
+	if f.include != nil && !matches(f.include) {
+		return false
+	}
+	if f.exclude != nil && matches(f.exclude) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogOptionsArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts LogOptions
+		want string
+	}{
+		{name: "default", opts: LogOptions{}, want: "--"},
+		{name: "range", opts: LogOptions{Revision: "v1.0..v2.0"}, want: "v1.0..v2.0 --"},
+		{name: "all with window", opts: LogOptions{All: true, Since: "2024-01-01", Until: "2024-06-30"}, want: "--since=2024-01-01 --until=2024-06-30 --all --"},
+		{name: "paths", opts: LogOptions{Revision: "main", Paths: []string{"services/api/", "go.mod"}}, want: "main -- services/api/ go.mod"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tt.opts.args(), " "); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogOptionsValidate(t *testing.T) {
+	if err := (LogOptions{All: true, Revision: "main"}).validate(); err == nil {
+		t.Error("expected an error combining a revision with all refs")
+	}
+	if err := (LogOptions{Revision: "--output=/tmp/x"}).validate(); err == nil {
+		t.Error("expected an error for a revision that looks like an option")
+	}
+}
+
+func TestAuthorFilter(t *testing.T) {
+	commits := []Commit{
+		{Author: "Jane Doe", Email: "jane@example.com"},
+		{Author: "dependabot[bot]", Email: "bot@github.com"},
+		{Author: "Bob", Email: "bob@corp.example"},
+	}
+
+	tests := []struct {
+		name string
+		opts LogOptions
+		want []bool
+	}{
+		{name: "none", want: []bool{true, true, true}},
+		{name: "include by email", opts: LogOptions{Author: `@example\.com$`}, want: []bool{true, false, false}},
+		{name: "exclude bots", opts: LogOptions{ExcludeAuthor: `\[bot\]`}, want: []bool{true, false, true}},
+		{name: "include and exclude", opts: LogOptions{Author: "example", ExcludeAuthor: "^Bob$"}, want: []bool{true, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.opts.authorFilter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range commits {
+				if got := filter.match(&commits[i]); got != tt.want[i] {
+					t.Errorf("commit %d: got %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := (LogOptions{Author: "("}).authorFilter(); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestAuthorFilterAfterAliases(t *testing.T) {
+	f := newFixture(t)
+	f.commit(ann, "First", nil)
+	f.commit(bob, "Second", nil)
+	f.commit(carol, "Third", nil)
+
+	aliases, err := ParseAliases(strings.NewReader("Robert = bob\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts LogOptions
+		want []string
+	}{
+		{name: "include canonical name", opts: LogOptions{Author: "^Robert$", Aliases: aliases}, want: []string{"Second"}},
+		{name: "exclude canonical name", opts: LogOptions{ExcludeAuthor: "^Robert$", Aliases: aliases}, want: []string{"First", "Third"}},
+		{name: "alias no longer matches", opts: LogOptions{Author: "^bob$", Aliases: aliases}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec, native := readBoth(t, f.dir, tt.opts)
+			for name, log := range map[string]*Log{"git": exec, "native": native} {
+				var got []string
+				for _, commit := range log.Commits {
+					got = append(got, commit.Message)
+					if commit.Message == "Second" && commit.Author != "Robert" {
+						t.Errorf("%s backend: author %q, want %q", name, commit.Author, "Robert")
+					}
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("%s backend: got %q, want %q", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
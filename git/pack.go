@@ -0,0 +1,438 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packfile is a pack of objects with its index, as written by git gc and
+// git fetch.
+type packfile struct {
+	path string
+	file *os.File
+
+	// The index: object names in sorted order, the cumulative count of
+	// names by first byte, and the offset of each object in the pack.
+	fanout  [256]uint32
+	names   []byte
+	offsets []int64
+
+	// bases caches resolved objects by offset, as delta chains share bases.
+	bases *objectCache[int64]
+}
+
+// packIndexMagic starts a version 2 pack index; version 1 indexes start
+// directly with the fanout table.
+var packIndexMagic = []byte{0xff, 't', 'O', 'c'}
+
+// openPacks opens every pack in the objects/pack directory.
+func openPacks(objectsDir string) ([]*packfile, error) {
+	indexes, err := filepath.Glob(filepath.Join(objectsDir, "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+
+	packs := make([]*packfile, 0, len(indexes))
+	for _, index := range indexes {
+		pack, err := openPack(strings.TrimSuffix(index, ".idx") + ".pack")
+		if err != nil {
+			closePacks(packs)
+			return nil, err
+		}
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
+// closePacks closes the pack files.
+func closePacks(packs []*packfile) {
+	for _, pack := range packs {
+		pack.file.Close()
+	}
+}
+
+// openPack reads the index of a pack and opens the pack itself.
+func openPack(path string) (*packfile, error) {
+	index, err := os.ReadFile(strings.TrimSuffix(path, ".pack") + ".idx")
+	if err != nil {
+		return nil, err
+	}
+
+	pack := &packfile{path: path, bases: newObjectCache[int64](256)}
+	if err := pack.parseIndex(index); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	if pack.file, err = os.Open(path); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 12)
+	if _, err := pack.file.ReadAt(header, 0); err != nil || string(header[:4]) != "PACK" {
+		pack.file.Close()
+		return nil, fmt.Errorf("%s: not a packfile", filepath.Base(path))
+	}
+	return pack, nil
+}
+
+// parseIndex parses a version 1 or 2 pack index.
+func (p *packfile) parseIndex(index []byte) error {
+	errCorrupt := errors.New("corrupt pack index")
+
+	version := 1
+	if bytes.HasPrefix(index, packIndexMagic) {
+		if len(index) < 8 {
+			return errCorrupt
+		}
+		version = int(binary.BigEndian.Uint32(index[4:]))
+		if version != 2 {
+			return fmt.Errorf("unsupported pack index version %d", version)
+		}
+		index = index[8:]
+	}
+
+	if len(index) < 256*4 {
+		return errCorrupt
+	}
+	// The counts are cumulative, so none exceeds the last, the number of
+	// objects.
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(index[i*4:])
+		if i > 0 && p.fanout[i] < p.fanout[i-1] {
+			return errCorrupt
+		}
+	}
+	index = index[256*4:]
+	count := int(p.fanout[255])
+
+	if version == 1 {
+		// Each entry is a 4-byte offset followed by the object name.
+		const entrySize = 4 + hashSize
+		if len(index) < count*entrySize {
+			return errCorrupt
+		}
+		p.names = make([]byte, 0, count*hashSize)
+		p.offsets = make([]int64, count)
+		for i := 0; i < count; i++ {
+			entry := index[i*entrySize:]
+			p.offsets[i] = int64(binary.BigEndian.Uint32(entry))
+			p.names = append(p.names, entry[4:entrySize]...)
+		}
+		return nil
+	}
+
+	// Names, CRC32s and 4-byte offsets, then the 8-byte offsets of large
+	// packs, referenced by 4-byte offsets with the high bit set.
+	if len(index) < count*(hashSize+4+4) {
+		return errCorrupt
+	}
+	p.names = index[:count*hashSize]
+	small := index[count*(hashSize+4):]
+	large := small[count*4:]
+
+	p.offsets = make([]int64, count)
+	for i := 0; i < count; i++ {
+		offset := binary.BigEndian.Uint32(small[i*4:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = int64(offset)
+			continue
+		}
+		at := int(offset&0x7fffffff) * 8
+		if len(large) < at+8 {
+			return errCorrupt
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(large[at:]))
+	}
+	return nil
+}
+
+// name returns the i-th object name of the index.
+func (p *packfile) name(i int) []byte {
+	return p.names[i*hashSize : (i+1)*hashSize]
+}
+
+// find returns the offset of the named object in the pack.
+func (p *packfile) find(id []byte) (int64, bool) {
+	low, high := p.bucket(id[0])
+	i := low + sort.Search(high-low, func(i int) bool {
+		return bytes.Compare(p.name(low+i), id) >= 0
+	})
+	if i < high && bytes.Equal(p.name(i), id) {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// findPrefix returns the names in the pack starting with the given hex
+// prefix.
+func (p *packfile) findPrefix(prefix string) []string {
+	var matches []string
+	first, err := hexByte(prefix)
+	if err != nil {
+		return nil
+	}
+	low, high := p.bucket(first)
+	for i := low; i < high; i++ {
+		if name := hex.EncodeToString(p.name(i)); strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+// bucket returns the range of index entries whose names start with b.
+func (p *packfile) bucket(b byte) (int, int) {
+	low := 0
+	if b > 0 {
+		low = int(p.fanout[b-1])
+	}
+	return low, int(p.fanout[b])
+}
+
+// maxDeltaDepth bounds delta chains, which git never makes longer than 4095
+// objects, so that a delta based on itself is reported instead of followed
+// forever.
+const maxDeltaDepth = 4095
+
+// object reads the object at offset, resolving deltas against their bases.
+// depth is the number of deltas already waiting for it as their base.
+func (p *packfile) object(r *repository, offset int64, depth int) (objectType, []byte, error) {
+	if cached, ok := p.bases.get(offset); ok {
+		return cached.typ, cached.data, nil
+	}
+	if depth > maxDeltaDepth {
+		return 0, nil, fmt.Errorf("delta chain at %d longer than %d objects", offset, maxDeltaDepth)
+	}
+
+	typ, size, dataOffset, err := p.entryHeader(offset)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var baseType objectType
+	var base []byte
+	switch typ {
+	case objectCommit, objectTree, objectBlob, objectTag:
+		data, err := p.inflate(dataOffset, size)
+		if err != nil {
+			return 0, nil, err
+		}
+		p.bases.put(offset, typ, data)
+		return typ, data, nil
+
+	case objectOfsDelta:
+		// The base is a negative offset, in a big-endian varint where each
+		// continuation adds one so that every value has one encoding.
+		buf := make([]byte, 10)
+		n, _ := p.file.ReadAt(buf, dataOffset)
+		distance := int64(0)
+		i := 0
+		for ; i < n; i++ {
+			if i > 0 {
+				distance++
+			}
+			distance = distance<<7 | int64(buf[i]&0x7f)
+			if buf[i]&0x80 == 0 {
+				break
+			}
+		}
+		if i == n || distance <= 0 || distance > offset {
+			return 0, nil, fmt.Errorf("invalid delta base offset at %d", offset)
+		}
+		dataOffset += int64(i + 1)
+		if baseType, base, err = p.object(r, offset-distance, depth+1); err != nil {
+			return 0, nil, err
+		}
+
+	case objectRefDelta:
+		id := make([]byte, hashSize)
+		if _, err := p.file.ReadAt(id, dataOffset); err != nil {
+			return 0, nil, err
+		}
+		dataOffset += hashSize
+		if baseType, base, err = r.chainedObject(hex.EncodeToString(id), depth+1); err != nil {
+			return 0, nil, err
+		}
+
+	default:
+		return 0, nil, fmt.Errorf("unknown object type %d at %d", typ, offset)
+	}
+
+	delta, err := p.inflate(dataOffset, size)
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := applyDelta(base, delta)
+	if err != nil {
+		return 0, nil, fmt.Errorf("delta at %d: %w", offset, err)
+	}
+	p.bases.put(offset, baseType, data)
+	return baseType, data, nil
+}
+
+// entryHeader reads the type and inflated size of the entry at offset, and
+// returns the offset following the header.
+func (p *packfile) entryHeader(offset int64) (objectType, int64, int64, error) {
+	buf := make([]byte, 16)
+	n, err := p.file.ReadAt(buf, offset)
+	if n == 0 {
+		return 0, 0, 0, fmt.Errorf("reading pack entry at %d: %w", offset, err)
+	}
+
+	// The first byte holds the type and the low four bits of the size;
+	// further bytes add seven bits each while the high bit is set.
+	typ := objectType(buf[0] >> 4 & 7)
+	size := int64(buf[0] & 0x0f)
+	shift := 4
+	i := 0
+	for buf[i]&0x80 != 0 {
+		i++
+		if i == n {
+			return 0, 0, 0, fmt.Errorf("truncated pack entry at %d", offset)
+		}
+		size |= int64(buf[i]&0x7f) << shift
+		shift += 7
+	}
+	return typ, size, offset + int64(i+1), nil
+}
+
+// maxPrealloc caps the memory allocated up front from a size recorded in a
+// pack, which a corrupt pack can set to anything; larger objects grow their
+// buffer as they are read.
+const maxPrealloc = 1 << 20
+
+// preallocSize returns the capacity to allocate for an object of size bytes.
+func preallocSize(size int64) int {
+	if size > maxPrealloc {
+		return maxPrealloc
+	}
+	return int(size)
+}
+
+// inflate decompresses size bytes of zlib data starting at offset.
+func (p *packfile) inflate(offset, size int64) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("pack entry at %d: invalid size %d", offset, size)
+	}
+	zr, err := zlib.NewReader(io.NewSectionReader(p.file, offset, 1<<62))
+	if err != nil {
+		return nil, fmt.Errorf("pack entry at %d: %w", offset, err)
+	}
+	defer zr.Close()
+
+	data := bytes.NewBuffer(make([]byte, 0, preallocSize(size)))
+	if _, err := io.CopyN(data, zr, size); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("pack entry at %d: %w", offset, err)
+	}
+	return data.Bytes(), nil
+}
+
+// errCorruptDelta reports a delta that does not apply to its base.
+var errCorruptDelta = errors.New("corrupt delta")
+
+// applyDelta rebuilds an object from its base and a delta: the base and
+// result sizes, then instructions that either copy a range of the base or
+// insert literal bytes.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	baseSize, delta := deltaSize(delta)
+	if baseSize != int64(len(base)) {
+		return nil, errCorruptDelta
+	}
+	resultSize, delta := deltaSize(delta)
+	if resultSize < 0 {
+		return nil, errCorruptDelta
+	}
+
+	result := make([]byte, 0, preallocSize(resultSize))
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case op&0x80 != 0:
+			// Bits 0-3 select the offset bytes present, bits 4-6 the size
+			// bytes; a size of zero means 0x10000.
+			var offset, size int64
+			for bit := 0; bit < 7; bit++ {
+				if op&(1<<bit) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, errCorruptDelta
+				}
+				if bit < 4 {
+					offset |= int64(delta[0]) << (8 * bit)
+				} else {
+					size |= int64(delta[0]) << (8 * (bit - 4))
+				}
+				delta = delta[1:]
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > int64(len(base)) {
+				return nil, errCorruptDelta
+			}
+			if int64(len(result))+size > resultSize {
+				return nil, errCorruptDelta
+			}
+			result = append(result, base[offset:offset+size]...)
+
+		case op != 0:
+			if int(op) > len(delta) || int64(len(result))+int64(op) > resultSize {
+				return nil, errCorruptDelta
+			}
+			result = append(result, delta[:op]...)
+			delta = delta[op:]
+
+		default:
+			return nil, errCorruptDelta
+		}
+	}
+
+	if int64(len(result)) != resultSize {
+		return nil, errCorruptDelta
+	}
+	return result, nil
+}
+
+// deltaSize reads a little-endian base-128 size from the start of a delta.
+// It returns -1 if the size is truncated or does not fit in an int64.
+func deltaSize(delta []byte) (int64, []byte) {
+	size := int64(0)
+	shift := 0
+	for i, b := range delta {
+		if shift > 56 {
+			return -1, nil
+		}
+		size |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return size, delta[i+1:]
+		}
+	}
+	return -1, nil
+}
+
+// hexByte parses the first two hex digits of s.
+func hexByte(s string) (byte, error) {
+	if len(s) < 2 {
+		return 0, errors.New("hex prefix too short")
+	}
+	b, err := hex.DecodeString(s[:2])
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
@@ -0,0 +1,524 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// repository reads objects and refs directly from a .git directory, without
+// the git binary.
+type repository struct {
+	// gitDir holds HEAD; commonDir holds the objects and refs, and differs
+	// from gitDir in linked worktrees.
+	gitDir    string
+	commonDir string
+
+	// workTree is the top of the working tree, empty for bare repositories,
+	// and prefix the opened path relative to it.
+	workTree string
+	prefix   string
+
+	packs   []*packfile
+	objects *objectCache[string]
+	shallow map[string]bool
+
+	packedRefs map[string]string
+}
+
+// openRepository opens the repository containing path, which may be the
+// working tree, a directory within it, or a bare repository.
+func openRepository(path string) (*repository, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return nil, err
+	}
+
+	r := &repository{objects: newObjectCache[string](4096)}
+	for dir := abs; ; {
+		if r.gitDir, err = gitDirAt(dir); err != nil {
+			return nil, err
+		}
+		if r.gitDir != "" {
+			if r.gitDir != dir {
+				r.workTree = dir
+				r.prefix, _ = filepath.Rel(dir, abs)
+			}
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("not a git repository: %s", path)
+		}
+		dir = parent
+	}
+
+	r.commonDir = r.gitDir
+	if common, err := os.ReadFile(filepath.Join(r.gitDir, "commondir")); err == nil {
+		r.commonDir = strings.TrimSpace(string(common))
+		if !filepath.IsAbs(r.commonDir) {
+			r.commonDir = filepath.Join(r.gitDir, r.commonDir)
+		}
+	}
+
+	if err := r.checkFormat(); err != nil {
+		return nil, err
+	}
+	if r.shallow, err = readShallow(filepath.Join(r.commonDir, "shallow")); err != nil {
+		return nil, err
+	}
+	if r.packs, err = openPacks(r.objectsDir()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// gitDirAt returns the Git directory of dir: its .git directory, the target
+// of a .git file, or dir itself if it is a bare repository. It returns ""
+// if dir is none of these.
+func gitDirAt(dir string) (string, error) {
+	dotGit := filepath.Join(dir, ".git")
+	info, err := os.Stat(dotGit)
+	switch {
+	case err == nil && info.IsDir():
+		return dotGit, nil
+
+	case err == nil:
+		// A worktree or submodule points at its Git directory.
+		content, err := os.ReadFile(dotGit)
+		if err != nil {
+			return "", err
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+		if !ok {
+			return "", fmt.Errorf("invalid .git file: %s", dotGit)
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		return target, nil
+	}
+
+	if isFile(filepath.Join(dir, "HEAD")) && isDir(filepath.Join(dir, "objects")) {
+		return dir, nil
+	}
+	return "", nil
+}
+
+// close closes the repository's packfiles.
+func (r *repository) close() {
+	closePacks(r.packs)
+}
+
+// objectsDir returns the directory of the object database.
+func (r *repository) objectsDir() string {
+	return filepath.Join(r.commonDir, "objects")
+}
+
+// checkFormat rejects repositories using extensions this reader does not
+// implement: SHA-256 object names and the reftable ref store.
+func (r *repository) checkFormat() error {
+	file, err := os.Open(filepath.Join(r.commonDir, "config"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		if section != "extensions" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case key == "objectformat" && value != "sha1":
+			return fmt.Errorf("unsupported object format: %s", value)
+		case key == "refstorage" && value != "files":
+			return fmt.Errorf("unsupported ref storage: %s", value)
+		}
+	}
+	return scanner.Err()
+}
+
+// readShallow reads the commits whose parents a shallow clone left out.
+func readShallow(path string) (map[string]bool, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	shallow := make(map[string]bool)
+	for _, hash := range strings.Fields(string(content)) {
+		shallow[hash] = true
+	}
+	return shallow, nil
+}
+
+// errRefNotFound reports a ref that does not exist.
+var errRefNotFound = errors.New("ref not found")
+
+// maxSymrefDepth bounds chains of symbolic refs.
+const maxSymrefDepth = 5
+
+// ref resolves a ref such as "HEAD" or "refs/heads/main" to an object name,
+// following symbolic refs.
+func (r *repository) ref(name string) (string, error) {
+	for depth := 0; depth < maxSymrefDepth; depth++ {
+		value, err := r.readRef(name)
+		if err != nil {
+			return "", err
+		}
+		target, ok := strings.CutPrefix(value, "ref: ")
+		if !ok {
+			return value, nil
+		}
+		name = strings.TrimSpace(target)
+	}
+	return "", fmt.Errorf("symbolic ref loop at %s", name)
+}
+
+// symbolicRef returns the ref a symbolic ref such as HEAD points to, or ""
+// if it holds an object name.
+func (r *repository) symbolicRef(name string) string {
+	value, err := r.readRef(name)
+	if err != nil {
+		return ""
+	}
+	target, _ := strings.CutPrefix(value, "ref: ")
+	if target == value {
+		return ""
+	}
+	return strings.TrimSpace(target)
+}
+
+// readRef returns the raw value of a ref: an object name, or "ref: <name>"
+// for a symbolic ref. Loose refs take precedence over packed refs.
+func (r *repository) readRef(name string) (string, error) {
+	dir := r.commonDir
+	if !strings.Contains(name, "/") {
+		// Pseudo-refs such as HEAD belong to the worktree.
+		dir = r.gitDir
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		return strings.TrimSpace(string(content)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) && !isDir(filepath.Join(dir, filepath.FromSlash(name))) {
+		return "", err
+	}
+
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return "", err
+	}
+	if hash, ok := packed[name]; ok {
+		return hash, nil
+	}
+	return "", fmt.Errorf("%w: %s", errRefNotFound, name)
+}
+
+// readPackedRefs reads the packed-refs file once. Peeled "^" lines are
+// skipped; tags are peeled when needed.
+func (r *repository) readPackedRefs() (map[string]string, error) {
+	if r.packedRefs != nil {
+		return r.packedRefs, nil
+	}
+
+	r.packedRefs = make(map[string]string)
+	content, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if errors.Is(err, os.ErrNotExist) {
+		return r.packedRefs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		if hash, name, ok := strings.Cut(line, " "); ok {
+			r.packedRefs[name] = hash
+		}
+	}
+	return r.packedRefs, nil
+}
+
+// refs returns every ref under refs/, loose and packed, with the object
+// name it resolves to.
+func (r *repository) refs() (map[string]string, error) {
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(packed))
+	for name := range packed {
+		names[name] = true
+	}
+
+	root := filepath.Join(r.commonDir, "refs")
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasSuffix(path, ".lock") {
+			return err
+		}
+		rel, err := filepath.Rel(r.commonDir, path)
+		if err != nil {
+			return err
+		}
+		names[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	refs := make(map[string]string, len(names))
+	for name := range names {
+		hash, err := r.ref(name)
+		if errors.Is(err, errRefNotFound) {
+			// A dangling symbolic ref.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		refs[name] = hash
+	}
+	return refs, nil
+}
+
+// refPatterns are the places a short ref name is looked up, in the order
+// git uses.
+var refPatterns = []string{
+	"%s",
+	"refs/%s",
+	"refs/tags/%s",
+	"refs/heads/%s",
+	"refs/remotes/%s",
+	"refs/remotes/%s/HEAD",
+}
+
+var (
+	hexName        = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+	revisionSuffix = regexp.MustCompile(`^(\^\{[a-z]*\}|[~^][0-9]*)`)
+)
+
+// resolve resolves a revision to a commit. It accepts full and abbreviated
+// object names, ref names, "@" for HEAD, and the suffixes "~n", "^n",
+// "^{}" and "^{commit}".
+func (r *repository) resolve(revision string) (string, error) {
+	base := revision
+	if i := strings.IndexAny(revision, "~^"); i >= 0 {
+		base = revision[:i]
+	}
+	if base == "" || base == "@" {
+		base = "HEAD"
+	}
+
+	hash, err := r.resolveName(base)
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q: %w", revision, err)
+	}
+
+	rest := revision[strings.IndexAny(revision+"~", "~^"):]
+	for rest != "" {
+		op := revisionSuffix.FindString(rest)
+		if op == "" {
+			return "", fmt.Errorf("unknown revision %q", revision)
+		}
+		rest = rest[len(op):]
+
+		if op == "^{}" {
+			if hash, _, err = r.peel(hash); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if hash, err = r.peelToCommit(hash); err != nil {
+			return "", fmt.Errorf("unknown revision %q: %w", revision, err)
+		}
+		if op == "^{commit}" {
+			continue
+		}
+		if strings.HasPrefix(op, "^{") {
+			return "", fmt.Errorf("unsupported revision %q", revision)
+		}
+
+		n := 1
+		if len(op) > 1 {
+			n, _ = strconv.Atoi(op[1:])
+		}
+		if hash, err = r.ancestor(hash, op[0], n); err != nil {
+			return "", fmt.Errorf("unknown revision %q: %w", revision, err)
+		}
+	}
+
+	if hash, err = r.peelToCommit(hash); err != nil {
+		return "", fmt.Errorf("unknown revision %q: %w", revision, err)
+	}
+	return hash, nil
+}
+
+// ancestor returns the n-th parent of a commit for "^", or its n-th
+// first-parent ancestor for "~".
+func (r *repository) ancestor(hash string, op byte, n int) (string, error) {
+	if op == '^' {
+		if n == 0 {
+			return hash, nil
+		}
+		commit, err := r.readCommit(hash)
+		if err != nil {
+			return "", err
+		}
+		if n > len(commit.parents) {
+			return "", fmt.Errorf("commit %s has no parent %d", hash, n)
+		}
+		return commit.parents[n-1], nil
+	}
+
+	for ; n > 0; n-- {
+		commit, err := r.readCommit(hash)
+		if err != nil {
+			return "", err
+		}
+		if len(commit.parents) == 0 {
+			return "", fmt.Errorf("commit %s has no parent", hash)
+		}
+		hash = commit.parents[0]
+	}
+	return hash, nil
+}
+
+// peelToCommit peels tags off the named object, which must lead to a commit.
+func (r *repository) peelToCommit(hash string) (string, error) {
+	hash, typ, err := r.peel(hash)
+	if err != nil {
+		return "", err
+	}
+	if typ != objectCommit {
+		return "", fmt.Errorf("object %s is a %s, not a commit", hash, typ)
+	}
+	return hash, nil
+}
+
+// resolveName resolves a full object name, a ref name or an abbreviated
+// object name.
+func (r *repository) resolveName(name string) (string, error) {
+	if len(name) == 2*hashSize && hexName.MatchString(name) {
+		return name, nil
+	}
+
+	for _, pattern := range refPatterns {
+		hash, err := r.ref(fmt.Sprintf(pattern, name))
+		if err == nil {
+			return hash, nil
+		}
+		if !errors.Is(err, errRefNotFound) {
+			return "", err
+		}
+	}
+
+	if hexName.MatchString(name) {
+		matches, err := r.findPrefix(name)
+		if err != nil {
+			return "", err
+		}
+		switch len(matches) {
+		case 0:
+		case 1:
+			return matches[0], nil
+		default:
+			return "", fmt.Errorf("short object name %s is ambiguous", name)
+		}
+	}
+	return "", errRefNotFound
+}
+
+// findPrefix returns the names of the objects starting with a hex prefix.
+func (r *repository) findPrefix(prefix string) ([]string, error) {
+	found := make(map[string]bool)
+	for _, pack := range r.packs {
+		for _, name := range pack.findPrefix(prefix) {
+			found[name] = true
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(r.objectsDir(), prefix[:2]))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if name := prefix[:2] + entry.Name(); strings.HasPrefix(name, prefix) && len(name) == 2*hashSize {
+			found[name] = true
+		}
+	}
+
+	matches := make([]string, 0, len(found))
+	for name := range found {
+		matches = append(matches, name)
+	}
+	return matches, nil
+}
+
+// readWorkTreeFile reads a file at the top of the working tree or, in a
+// bare repository, from the tree of HEAD.
+func (r *repository) readWorkTreeFile(name string) ([]byte, error) {
+	if r.workTree != "" {
+		return os.ReadFile(filepath.Join(r.workTree, name))
+	}
+
+	head, err := r.resolve("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.readCommit(head)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.readTree(commit.tree)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.name == name && !entry.isTree() {
+			return r.typedObject(entry.hash, objectBlob)
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
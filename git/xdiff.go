@@ -0,0 +1,400 @@
+package git
+
+import "math"
+
+// The line diff below follows git's xdiff, the diff behind git log
+// --numstat, so that both backends count the same changed lines. xdiff is
+// not a minimal diff: lines with many matches may be discarded up front,
+// and expensive searches settle for a good enough split.
+const (
+	// maxEqualLimit caps the number of matches above which a line counts
+	// as matching many lines (XDL_MAX_EQLIMIT).
+	maxEqualLimit = 1024
+	// simscanWindow bounds the scan for runs around such lines
+	// (XDL_SIMSCAN_WINDOW).
+	simscanWindow = 100
+	// keepDiscardRun weighs kept against discarded lines in a run
+	// (XDL_KPDIS_RUN).
+	keepDiscardRun = 4
+
+	// Search heuristics: the minimum cost limit, the cost from which long
+	// snakes are taken as split points, the length of such a snake, and
+	// the factor by which progress must exceed the cost (XDL_MAX_COST_MIN,
+	// XDL_HEUR_MIN_COST, XDL_SNAKE_CNT and XDL_K_HEUR).
+	minCostLimit    = 256
+	heuristicCost   = 256
+	snakeLength     = 20
+	heuristicFactor = 4
+)
+
+// diffLines marks the lines of two files, given as line class numbers,
+// that are deleted from a or added in b.
+func diffLines(a, b []int) (deleted, added []bool) {
+	deleted, added = make([]bool, len(a)), make([]bool, len(b))
+
+	classes := 0
+	for _, class := range append(append([]int(nil), a...), b...) {
+		if class >= classes {
+			classes = class + 1
+		}
+	}
+	countA, countB := make([]int, classes), make([]int, classes)
+	for _, class := range a {
+		countA[class]++
+	}
+	for _, class := range b {
+		countB[class]++
+	}
+
+	// Lines shared at the start and end take no part in the diff.
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+	end := 0
+	for end < len(a)-start && end < len(b)-start && a[len(a)-1-end] == b[len(b)-1-end] {
+		end++
+	}
+
+	reducedA, indexA := discardLines(a, start, len(a)-1-end, countB, deleted)
+	reducedB, indexB := discardLines(b, start, len(b)-1-end, countA, added)
+
+	d := &lineDiff{
+		a: reducedA, b: reducedB,
+		indexA: indexA, indexB: indexB,
+		deleted: deleted, added: added,
+	}
+	diagonals := len(reducedA) + len(reducedB) + 3
+	d.forward = make([]int, diagonals)
+	d.backward = make([]int, diagonals)
+	d.offset = len(reducedB) + 1
+	d.maxCost = bogoSqrt(diagonals)
+	if d.maxCost < minCostLimit {
+		d.maxCost = minCostLimit
+	}
+
+	d.compare(0, len(reducedA), 0, len(reducedB), false)
+	return deleted, added
+}
+
+// discardLines marks the lines of lines[start:end+1] without a match in
+// the other file as changed, and those with many matches too when they sit
+// among unmatched lines. It returns the remaining lines and their indexes.
+func discardLines(lines []int, start, end int, otherCounts []int, changed []bool) ([]int, []int) {
+	limit := bogoSqrt(len(lines))
+	if limit > maxEqualLimit {
+		limit = maxEqualLimit
+	}
+
+	// 0: no match, 1: some matches, 2: many matches.
+	matches := make([]byte, len(lines)+1)
+	for i := start; i <= end; i++ {
+		switch n := otherCounts[lines[i]]; {
+		case n == 0:
+			matches[i] = 0
+		case n >= limit:
+			matches[i] = 2
+		default:
+			matches[i] = 1
+		}
+	}
+
+	var kept, index []int
+	for i := start; i <= end; i++ {
+		if matches[i] == 1 || (matches[i] == 2 && !discardMultimatch(matches, i, start, end)) {
+			kept = append(kept, lines[i])
+			index = append(index, i)
+		} else {
+			changed[i] = true
+		}
+	}
+	return kept, index
+}
+
+// discardMultimatch reports whether line i, which has many matches, lies
+// in a run dominated by lines without any.
+func discardMultimatch(matches []byte, i, start, end int) bool {
+	if i-start > simscanWindow {
+		start = i - simscanWindow
+	}
+	if end-i > simscanWindow {
+		end = i + simscanWindow
+	}
+
+	unmatchedBefore, multiBefore := 0, 1
+	for r := 1; i-r >= start; r++ {
+		if matches[i-r] == 0 {
+			unmatchedBefore++
+		} else if matches[i-r] == 2 {
+			multiBefore++
+		} else {
+			break
+		}
+	}
+	if unmatchedBefore == 0 {
+		return false
+	}
+
+	unmatchedAfter, multiAfter := 0, 1
+	for r := 1; i+r <= end; r++ {
+		if matches[i+r] == 0 {
+			unmatchedAfter++
+		} else if matches[i+r] == 2 {
+			multiAfter++
+		} else {
+			break
+		}
+	}
+	if unmatchedAfter == 0 {
+		return false
+	}
+
+	unmatched := unmatchedBefore + unmatchedAfter
+	multi := multiBefore + multiAfter
+	return multi*keepDiscardRun < multi+unmatched
+}
+
+// bogoSqrt approximates the square root of n by a power of two, as xdiff
+// does.
+func bogoSqrt(n int) int {
+	i := 1
+	for ; n > 0; n >>= 2 {
+		i <<= 1
+	}
+	return i
+}
+
+// lineDiff is the state of a divide-and-conquer diff of two line sequences.
+type lineDiff struct {
+	a, b           []int
+	indexA, indexB []int
+	deleted, added []bool
+
+	// forward and backward hold the furthest line of a reached on each
+	// diagonal, offset so that negative diagonals fit.
+	forward, backward []int
+	offset            int
+	maxCost           int
+}
+
+// split is a point the diff passes through, and whether each half must be
+// diffed minimally.
+type split struct {
+	i1, i2             int
+	minLower, minUpper bool
+}
+
+// compare marks the changed lines between a[off1:lim1] and b[off2:lim2].
+func (d *lineDiff) compare(off1, lim1, off2, lim2 int, needMin bool) {
+	for off1 < lim1 && off2 < lim2 && d.a[off1] == d.b[off2] {
+		off1, off2 = off1+1, off2+1
+	}
+	for off1 < lim1 && off2 < lim2 && d.a[lim1-1] == d.b[lim2-1] {
+		lim1, lim2 = lim1-1, lim2-1
+	}
+
+	switch {
+	case off1 == lim1:
+		for ; off2 < lim2; off2++ {
+			d.added[d.indexB[off2]] = true
+		}
+	case off2 == lim2:
+		for ; off1 < lim1; off1++ {
+			d.deleted[d.indexA[off1]] = true
+		}
+	default:
+		s := d.split(off1, lim1, off2, lim2, needMin)
+		d.compare(off1, s.i1, off2, s.i2, s.minLower)
+		d.compare(s.i1, lim1, s.i2, lim2, s.minUpper)
+	}
+}
+
+// split finds where the shortest edit script between a[off1:lim1] and
+// b[off2:lim2] crosses its middle, searching forward and backward at once.
+// Unless needMin is set, it gives up on long searches with a good split.
+func (d *lineDiff) split(off1, lim1, off2, lim2 int, needMin bool) split {
+	kf := func(k int) *int { return &d.forward[d.offset+k] }
+	kb := func(k int) *int { return &d.backward[d.offset+k] }
+
+	dmin, dmax := off1-lim2, lim1-off2
+	fmid, bmid := off1-off2, lim1-lim2
+	odd := (fmid-bmid)&1 != 0
+	fmin, fmax := fmid, fmid
+	bmin, bmax := bmid, bmid
+
+	*kf(fmid) = off1
+	*kb(bmid) = lim1
+
+	for cost := 1; ; cost++ {
+		gotSnake := false
+
+		// Extend the forward diagonals by one.
+		if fmin > dmin {
+			fmin--
+			*kf(fmin - 1) = -1
+		} else {
+			fmin++
+		}
+		if fmax < dmax {
+			fmax++
+			*kf(fmax + 1) = -1
+		} else {
+			fmax--
+		}
+
+		for k := fmax; k >= fmin; k -= 2 {
+			var i1 int
+			if *kf(k - 1) >= *kf(k + 1) {
+				i1 = *kf(k - 1) + 1
+			} else {
+				i1 = *kf(k + 1)
+			}
+			prev := i1
+			i2 := i1 - k
+			for i1 < lim1 && i2 < lim2 && d.a[i1] == d.b[i2] {
+				i1, i2 = i1+1, i2+1
+			}
+			if i1-prev > snakeLength {
+				gotSnake = true
+			}
+			*kf(k) = i1
+			if odd && bmin <= k && k <= bmax && *kb(k) <= i1 {
+				return split{i1: i1, i2: i2, minLower: true, minUpper: true}
+			}
+		}
+
+		// Extend the backward diagonals by one.
+		if bmin > dmin {
+			bmin--
+			*kb(bmin - 1) = math.MaxInt
+		} else {
+			bmin++
+		}
+		if bmax < dmax {
+			bmax++
+			*kb(bmax + 1) = math.MaxInt
+		} else {
+			bmax--
+		}
+
+		for k := bmax; k >= bmin; k -= 2 {
+			var i1 int
+			if *kb(k - 1) < *kb(k + 1) {
+				i1 = *kb(k - 1)
+			} else {
+				i1 = *kb(k + 1) - 1
+			}
+			prev := i1
+			i2 := i1 - k
+			for i1 > off1 && i2 > off2 && d.a[i1-1] == d.b[i2-1] {
+				i1, i2 = i1-1, i2-1
+			}
+			if prev-i1 > snakeLength {
+				gotSnake = true
+			}
+			*kb(k) = i1
+			if !odd && fmin <= k && k <= fmax && i1 <= *kf(k) {
+				return split{i1: i1, i2: i2, minLower: true, minUpper: true}
+			}
+		}
+
+		if needMin {
+			continue
+		}
+
+		// Past the heuristic cost, split at a diagonal that has come far
+		// along a long snake.
+		if gotSnake && cost > heuristicCost {
+			best := 0
+			var s split
+			for k := fmax; k >= fmin; k -= 2 {
+				dd := abs(k - fmid)
+				i1 := *kf(k)
+				i2 := i1 - k
+				v := (i1 - off1) + (i2 - off2) - dd
+				if v > heuristicFactor*cost && v > best &&
+					off1+snakeLength <= i1 && i1 < lim1 &&
+					off2+snakeLength <= i2 && i2 < lim2 {
+					for n := 1; d.a[i1-n] == d.b[i2-n]; n++ {
+						if n == snakeLength {
+							best = v
+							s = split{i1: i1, i2: i2}
+							break
+						}
+					}
+				}
+			}
+			if best > 0 {
+				s.minLower, s.minUpper = true, false
+				return s
+			}
+
+			for k := bmax; k >= bmin; k -= 2 {
+				dd := abs(k - bmid)
+				i1 := *kb(k)
+				i2 := i1 - k
+				v := (lim1 - i1) + (lim2 - i2) - dd
+				if v > heuristicFactor*cost && v > best &&
+					off1 < i1 && i1 <= lim1-snakeLength &&
+					off2 < i2 && i2 <= lim2-snakeLength {
+					for n := 0; d.a[i1+n] == d.b[i2+n]; n++ {
+						if n == snakeLength-1 {
+							best = v
+							s = split{i1: i1, i2: i2}
+							break
+						}
+					}
+				}
+			}
+			if best > 0 {
+				s.minLower, s.minUpper = false, true
+				return s
+			}
+		}
+
+		// Enough: take the furthest point reached in either direction.
+		if cost >= d.maxCost {
+			fbest, fbest1 := -1, -1
+			for k := fmax; k >= fmin; k -= 2 {
+				i1 := *kf(k)
+				if i1 > lim1 {
+					i1 = lim1
+				}
+				i2 := i1 - k
+				if lim2 < i2 {
+					i1, i2 = lim2+k, lim2
+				}
+				if fbest < i1+i2 {
+					fbest, fbest1 = i1+i2, i1
+				}
+			}
+
+			bbest, bbest1 := math.MaxInt, math.MaxInt
+			for k := bmax; k >= bmin; k -= 2 {
+				i1 := *kb(k)
+				if i1 < off1 {
+					i1 = off1
+				}
+				i2 := i1 - k
+				if i2 < off2 {
+					i1, i2 = off2+k, off2
+				}
+				if i1+i2 < bbest {
+					bbest, bbest1 = i1+i2, i1
+				}
+			}
+
+			if (lim1+lim2)-bbest < fbest-(off1+off2) {
+				return split{i1: fbest1, i2: fbest - fbest1, minLower: true}
+			}
+			return split{i1: bbest1, i2: bbest - bbest1, minUpper: true}
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	"path/filepath"
@@ -11,6 +13,7 @@ import (
 	"github.com/klejdi94/git2midi/audio"
 	"github.com/klejdi94/git2midi/config"
 	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
 	"github.com/klejdi94/git2midi/music"
 )
 
@@ -39,20 +42,94 @@ func parseFlags() *config.Config {
 	flag.StringVar(&cfg.RepoPath, "repo", config.DefaultRepoPath,
 		"Path to Git repository or Git repository URL (http://, https://, git://, ssh://, or git@)")
 	flag.StringVar(&cfg.OutputPath, "out", config.DefaultOutputPath,
-		"Output file path (MIDI or audio format: .mid, .mp3, .wav, .ogg, .flac, .aac, .m4a)")
+		"Output file path (MIDI, karaoke or audio format: .mid, .kar, .mp3, .wav, .ogg, .flac, .aac, .m4a)")
 	flag.IntVar(&cfg.BPM, "bpm", config.DefaultBPM,
 		fmt.Sprintf("Tempo in BPM (default: %d for modern feel)", config.DefaultBPM))
 	flag.IntVar(&cfg.Ticks, "ticks", config.DefaultTicks,
 		"Ticks per quarter note")
 	flag.IntVar(&cfg.Duration, "dur", config.DefaultDuration,
 		fmt.Sprintf("Duration of each note in ticks (default: %d for faster playback)", config.DefaultDuration))
+	flag.StringVar(&cfg.Soundfont, "soundfont", "",
+		"SoundFont 2 (.sf2) file used to render audio output with realistic instruments")
+	flag.StringVar(&cfg.Renderer, "renderer", config.DefaultRenderer,
+		"MIDI renderer for audio output: 'auto', "+strings.Join(audio.RendererNames(), ", "))
+	flag.StringVar(&cfg.Backend, "backend", config.DefaultBackend,
+		"Git history reader: 'auto', "+strings.Join(git.BackendNames(), ", ")+" (built-in, no git binary needed)")
 	flag.IntVar(&cfg.MaxCommits, "limit", 0,
 		"Maximum number of commits to process (0 = all, recommended: 500-2000 for large repos)")
 	flag.BoolVar(&cfg.Sample, "sample", false,
 		"Evenly sample commits instead of taking first N (useful with -limit)")
 
+	flag.StringVar(&cfg.Revision, "rev", "",
+		"Revision or range to read, e.g. 'main' or 'v1.0..v2.0' (default: HEAD)")
+	flag.BoolVar(&cfg.All, "all", false,
+		"Read the history of all branches and tags")
+	flag.StringVar(&cfg.Since, "since", "",
+		"Only commits after this date, e.g. '2024-01-01' or '6 months ago'")
+	flag.StringVar(&cfg.Until, "until", "",
+		"Only commits before this date")
+	flag.StringVar(&cfg.Author, "author", "",
+		"Only commits whose author name or email matches this regular expression")
+	flag.StringVar(&cfg.ExcludeAuthor, "exclude-author", "",
+		"Skip commits whose author name or email matches this regular expression, e.g. '\\[bot\\]'")
+	flag.Var((*stringList)(&cfg.Paths), "path",
+		"Only commits touching this path, e.g. 'services/api/' (repeatable)")
+
+	flag.StringVar(&cfg.Scale, "scale", config.DefaultScale,
+		"Scale name ("+strings.Join(music.ScaleNames(), ", ")+") or comma-separated semitone intervals, e.g. '0,2,3,7,9'")
+	flag.StringVar(&cfg.Key, "key", config.DefaultKey,
+		"Root key of the scale, e.g. 'C', 'F#', 'Bb'")
+	flag.StringVar(&cfg.Range, "range", config.DefaultRange,
+		"Pitch range as LOW-HIGH note names or MIDI numbers, e.g. 'C4-C6' or '60-84'")
+
+	flag.StringVar(&cfg.Instrument, "instrument", config.DefaultInstrument,
+		"General MIDI instrument name or program number (0-127), e.g. 'electric bass', 'flute', '40'")
+	flag.StringVar(&cfg.AuthorInstruments, "author-instruments", "",
+		"Per-author instruments in per-author mode, e.g. 'Alice=flute,Bob=cello'")
+	flag.StringVar(&cfg.AliasFile, "aliases", "",
+		"File of author aliases, one person per line: 'Jane Doe = jane, J. Doe, jane@example.com'")
+
+	lyricsStr := flag.String("lyrics", "off",
+		"Commit subjects as lyrics: 'off', 'subject' (one lyric per commit) or 'words' (one lyric per word)")
+	flag.BoolVar(&cfg.Karaoke, "kar", false,
+		"Write a karaoke (.kar) file with commit subjects as words (implied by a .kar output)")
+
+	flag.BoolVar(&cfg.Drums, "drums", false,
+		"Add a percussion track on MIDI channel 10 (merges: crash, reverts: snare roll, large diffs: kick, others: hi-hat)")
+
+	harmonyStr := flag.String("harmony", "off",
+		"Add pad and bass tracks with a chord per 'week', 'month' or 'cluster' of commits, or 'off'")
+
+	flag.BoolVar(&cfg.Conventional, "conventional", false,
+		"Play Conventional Commits by type: features rise, fixes resolve, docs and tests an octave up, refactors and chores an octave down")
+	flag.IntVar(&cfg.KeyChange, "key-change", music.DefaultKeyChange,
+		"Semitones the key moves at each breaking change with -conventional (0 = no key change)")
+
+	tagAccentStr := flag.String("tag-accent", "none",
+		"Accent played at tagged commits: 'none', 'cadence' or 'cymbal'")
+	flag.BoolVar(&cfg.MarkBranches, "mark-branches", false,
+		"Also add markers at commits that are branch heads")
+
+	allocationStr := flag.String("allocation", "top",
+		"Per-author voice allocation: 'top' (most active authors get a channel, the rest share 'Others') or 'hash' (authors hashed onto channels)")
+	flag.IntVar(&cfg.Voices, "voices", 0,
+		"Maximum dedicated author voices for 'top' allocation (0 = one per free channel)")
+
+	flag.IntVar(&cfg.CoAuthorInterval, "coauthor-interval", config.DefaultCoAuthorInterval,
+		"Scale steps between the voices of a commit's author and each Co-authored-by co-author in per-author mode (0 = unison, 2 = third)")
+
 	modeStr := flag.String("mode", "single-track",
-		"Mode: 'single-track' or 'per-author'")
+		"Mode: 'single-track', 'per-author' or 'lanes' (a voice per branch lane of the commit graph)")
+	rhythmStr := flag.String("rhythm", "fixed",
+		"Rhythm: 'fixed' pattern or 'timestamp' (space notes by real time between commits)")
+	compressionStr := flag.String("compress", "log",
+		"Timestamp rhythm compression: 'linear', 'log' or 'quantize'")
+	flag.DurationVar(&cfg.BeatDuration, "beat", config.DefaultBeatDuration,
+		"Wall-clock gap between commits mapped to one beat in timestamp rhythm")
+	flag.IntVar(&cfg.Grid, "grid", 0,
+		"Quantization grid in ticks for 'quantize' compression (0 = sixteenth note)")
+	flag.IntVar(&cfg.MaxSilence, "max-silence", config.DefaultMaxSilence,
+		"Longest rest between commits in beats for timestamp rhythm (0 = no cap)")
 
 	showVersion := flag.Bool("version", false, "Show version information")
 	flag.Parse()
@@ -69,58 +146,128 @@ func parseFlags() *config.Config {
 	}
 	cfg.Mode = mode
 
+	rhythm, err := config.ParseRhythm(*rhythmStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Rhythm = rhythm
+
+	compression, err := config.ParseCompression(*compressionStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Compression = compression
+
+	lyrics, err := config.ParseLyrics(*lyricsStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Lyrics = lyrics
+
+	tagAccent, err := config.ParseAccent(*tagAccentStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.TagAccent = tagAccent
+
+	harmony, err := config.ParseHarmony(*harmonyStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Harmony = harmony
+
+	allocation, err := config.ParseAllocation(*allocationStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Allocation = allocation
+
 	return cfg
 }
 
 func run(cfg *config.Config) error {
-	fmt.Printf("Reading commits from: %s\n", cfg.RepoPath)
-	commits, err := git.ParseLog(cfg.RepoPath)
+	genCfg, err := newGeneratorConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to read git log: %w", err)
+		return err
 	}
 
-	if len(commits) == 0 {
-		return fmt.Errorf("no commits found in repository")
+	var aliases git.Aliases
+	if cfg.AliasFile != "" {
+		aliases, err = git.LoadAliases(cfg.AliasFile)
+		if err != nil {
+			return err
+		}
 	}
 
-	originalCount := len(commits)
+	backend, err := git.SelectBackend(cfg.Backend)
+	if err != nil {
+		return err
+	}
 
-	if cfg.MaxCommits > 0 && len(commits) > cfg.MaxCommits {
-		if cfg.Sample {
-			commits, err = git.SampleCommits(commits, cfg.MaxCommits)
-			if err != nil {
-				return fmt.Errorf("failed to sample commits: %w", err)
-			}
-			fmt.Printf("Sampled %d commits from %d total\n", len(commits), originalCount)
-		} else {
-			commits = git.LimitCommits(commits, cfg.MaxCommits)
-			fmt.Printf("Limited to first %d commits from %d total\n", len(commits), originalCount)
-		}
-	} else {
-		fmt.Printf("Found %d commits\n", len(commits))
+	fmt.Printf("Reading commits from: %s (%s backend)\n", cfg.RepoPath, backend.Name())
+	history, err := backend.OpenLog(cfg.RepoPath, git.LogOptions{
+		Revision:      cfg.Revision,
+		All:           cfg.All,
+		Since:         cfg.Since,
+		Until:         cfg.Until,
+		Author:        cfg.Author,
+		ExcludeAuthor: cfg.ExcludeAuthor,
+		Paths:         cfg.Paths,
+		Aliases:       aliases,
+		Reverse:       true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read git log: %w", err)
 	}
+	defer history.Close()
 
-	genCfg := &music.Config{
-		BPM:      cfg.BPM,
-		Ticks:    cfg.Ticks,
-		Duration: cfg.Duration,
-		Mode:     music.Mode(cfg.Mode),
+	// Without sampling, stop reading the history once the limit is reached.
+	var source git.CommitIterator = history
+	if cfg.MaxCommits > 0 && !cfg.Sample {
+		source = git.Limit(history, cfg.MaxCommits)
 	}
 
 	generator := music.NewGenerator(genCfg)
 
-	fmt.Printf("Generating MIDI composition...\n")
-	writer, err := generator.Generate(commits)
+	// A single track in fixed rhythm is played as commits are read; voices,
+	// harmony and sampling need the whole history, which is collected first.
+	var writer *midi.Writer
+	if generator.Streams() && !(cfg.MaxCommits > 0 && cfg.Sample) {
+		writer, err = generateStreaming(generator, history, source, cfg.MaxCommits)
+	} else {
+		writer, err = generateCollected(generator, history, source, cfg)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to generate MIDI: %w", err)
+		return err
+	}
+
+	if voices := generator.Voices(); len(voices) > 0 {
+		if cfg.Mode == config.ModeLanes {
+			printLanes(voices)
+		} else {
+			printVoices(voices, cfg.Allocation)
+		}
 	}
 
 	// Determine output format from extension
 	outputExt := strings.ToLower(filepath.Ext(cfg.OutputPath))
-	isAudioFormat := outputExt != "" && outputExt != ".mid" && outputExt != ".midi"
+
+	isAudioFormat := outputExt != "" && outputExt != ".mid" && outputExt != ".midi" && outputExt != ".kar"
 
 	var midiPath string
+	var renderer audio.Renderer
 	if isAudioFormat {
+		renderer, err = audio.SelectRenderer(cfg.Renderer, cfg.Soundfont)
+		if err != nil {
+			return err
+		}
+
 		// Generate MIDI first, then convert
 		midiPath = strings.TrimSuffix(cfg.OutputPath, outputExt) + ".mid"
 	} else {
@@ -133,16 +280,17 @@ func run(cfg *config.Config) error {
 	}
 
 	if isAudioFormat {
-		fmt.Printf("Converting to %s format...\n", strings.TrimPrefix(outputExt, "."))
+		format := strings.TrimPrefix(outputExt, ".")
 		converter := audio.NewConverter("")
-		if !converter.IsAvailable() {
+		converter.SetRenderer(renderer)
+		if audio.NeedsEncoder(format) && !converter.IsAvailable() {
 			fmt.Fprintf(os.Stderr, "Warning: ffmpeg not found. Audio conversion skipped.\n")
-			fmt.Fprintf(os.Stderr, "Install ffmpeg to convert MIDI to audio formats.\n")
+			fmt.Fprintf(os.Stderr, "Install ffmpeg to encode %s, or use a .wav output.\n", format)
 			fmt.Printf("MIDI file saved as: %s\n", midiPath)
 			return nil
 		}
 
-		format := strings.TrimPrefix(outputExt, ".")
+		fmt.Printf("Rendering %s audio with %s...\n", format, renderer.Name())
 		if err := converter.Convert(midiPath, cfg.OutputPath, format); err != nil {
 			return fmt.Errorf("failed to convert to audio: %w", err)
 		}
@@ -159,3 +307,229 @@ func run(cfg *config.Config) error {
 
 	return nil
 }
+
+// newGeneratorConfig builds the music generator configuration from the CLI configuration.
+// generateStreaming generates the composition while reading the commits of
+// source, without keeping them.
+func generateStreaming(generator *music.Generator, history git.LogReader, source git.CommitIterator, limit int) (*midi.Writer, error) {
+	fmt.Printf("Generating MIDI composition...\n")
+	counted := &countingIterator{CommitIterator: source}
+	writer, err := generator.GenerateFrom(counted)
+	history.Close()
+
+	if counted.err != nil {
+		return nil, fmt.Errorf("failed to read git log: %w", counted.err)
+	}
+	if history.Skipped() > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d commit(s) that could not be parsed\n", history.Skipped())
+	}
+	if errors.Is(err, music.ErrNoCommits) {
+		return nil, fmt.Errorf("no commits found in repository")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate MIDI: %w", err)
+	}
+
+	if limit > 0 && counted.count == limit {
+		fmt.Printf("Limited to first %d commits\n", counted.count)
+	} else {
+		fmt.Printf("Found %d commits\n", counted.count)
+	}
+	return writer, nil
+}
+
+// generateCollected reads all commits of source, samples them if asked to,
+// and generates the composition.
+func generateCollected(generator *music.Generator, history git.LogReader, source git.CommitIterator, cfg *config.Config) (*midi.Writer, error) {
+	commits, err := git.Collect(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git log: %w", err)
+	}
+	history.Close()
+
+	if history.Skipped() > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d commit(s) that could not be parsed\n", history.Skipped())
+	}
+
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits found in repository")
+	}
+
+	switch {
+	case cfg.MaxCommits > 0 && cfg.Sample && len(commits) > cfg.MaxCommits:
+		originalCount := len(commits)
+		commits, err = git.SampleCommits(commits, cfg.MaxCommits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sample commits: %w", err)
+		}
+		fmt.Printf("Sampled %d commits from %d total\n", len(commits), originalCount)
+	case cfg.MaxCommits > 0 && !cfg.Sample && len(commits) == cfg.MaxCommits:
+		fmt.Printf("Limited to first %d commits\n", len(commits))
+	default:
+		fmt.Printf("Found %d commits\n", len(commits))
+	}
+
+	fmt.Printf("Generating MIDI composition...\n")
+	writer, err := generator.Generate(commits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate MIDI: %w", err)
+	}
+	return writer, nil
+}
+
+// countingIterator counts the commits read from an iterator and keeps the
+// error that stopped it, if any.
+type countingIterator struct {
+	git.CommitIterator
+	count int
+	err   error
+}
+
+func (c *countingIterator) Next() (git.Commit, error) {
+	commit, err := c.CommitIterator.Next()
+	if err == nil {
+		c.count++
+	} else if err != io.EOF {
+		c.err = err
+	}
+	return commit, err
+}
+
+func newGeneratorConfig(cfg *config.Config) (*music.Config, error) {
+	scale, err := music.ParseScale(cfg.Scale)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := music.ParseKey(cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	low, high, err := music.ParseRange(cfg.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scale.Notes(root, low, high)) == 0 {
+		return nil, fmt.Errorf("pitch range %s contains no notes of the %s scale", cfg.Range, scale.Name)
+	}
+
+	instrument, err := midi.LookupInstrument(cfg.Instrument)
+	if err != nil {
+		return nil, err
+	}
+
+	authorInstruments, err := parseAuthorInstruments(cfg.AuthorInstruments)
+	if err != nil {
+		return nil, err
+	}
+
+	var types *music.TypeMapping
+	if cfg.Conventional {
+		types = music.DefaultTypeMapping()
+		types.KeyChange = cfg.KeyChange
+	}
+
+	return &music.Config{
+		Title:    git.RepoName(cfg.RepoPath),
+		BPM:      cfg.BPM,
+		Ticks:    cfg.Ticks,
+		Duration: cfg.Duration,
+		Mode:     music.Mode(cfg.Mode),
+		Rhythm:   music.Rhythm(cfg.Rhythm),
+		Time: music.TimeMapping{
+			Compression:  music.Compression(cfg.Compression),
+			BeatDuration: cfg.BeatDuration,
+			Grid:         uint32(cfg.Grid),
+			MaxSilence:   uint32(cfg.MaxSilence * cfg.Ticks),
+		},
+		Scale:    scale,
+		Root:     root,
+		LowNote:  low,
+		HighNote: high,
+
+		Instrument:        instrument,
+		AuthorInstruments: authorInstruments,
+
+		Lyrics:  music.Lyrics(cfg.Lyrics),
+		Karaoke: cfg.Karaoke || strings.EqualFold(filepath.Ext(cfg.OutputPath), ".kar"),
+
+		TagAccent:    music.Accent(cfg.TagAccent),
+		MarkBranches: cfg.MarkBranches,
+
+		Drums:   cfg.Drums,
+		Harmony: music.Harmony(cfg.Harmony),
+		Types:   types,
+
+		Allocation:       music.Allocation(cfg.Allocation),
+		Voices:           cfg.Voices,
+		CoAuthorInterval: cfg.CoAuthorInterval,
+	}, nil
+}
+
+// printVoices reports how authors were allocated to voices.
+func printVoices(voices []music.Voice, allocation config.Allocation) {
+	authors := 0
+	for _, voice := range voices {
+		authors += len(voice.Authors)
+	}
+
+	fmt.Printf("Allocated %d author(s) to %d voice(s) (%s):\n", authors, len(voices), allocation)
+	for _, voice := range voices {
+		name := voice.Name
+		if len(voice.Authors) > 1 {
+			name = fmt.Sprintf("%s (%d authors)", voice.Name, len(voice.Authors))
+		}
+		fmt.Printf("  channel %2d  %-24s %s\n", voice.Channel+1, midi.ProgramName(voice.Program), name)
+	}
+}
+
+// printLanes reports how branch lanes were assigned to voices.
+func printLanes(voices []music.Voice) {
+	lanes := 0
+	for _, voice := range voices {
+		lanes += len(voice.Lanes)
+	}
+
+	fmt.Printf("Assigned %d branch lane(s) to %d voice(s):\n", lanes, len(voices))
+	for _, voice := range voices {
+		fmt.Printf("  channel %2d  %-24s %s\n", voice.Channel+1, midi.ProgramName(voice.Program), voice.Name)
+	}
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseAuthorInstruments parses a comma-separated list of author=instrument pairs.
+func parseAuthorInstruments(s string) (map[string]byte, error) {
+	instruments := make(map[string]byte)
+	if strings.TrimSpace(s) == "" {
+		return instruments, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		author, name, ok := strings.Cut(pair, "=")
+		author = strings.TrimSpace(author)
+		if !ok || author == "" {
+			return nil, fmt.Errorf("invalid author instrument %q (expected author=instrument)", pair)
+		}
+
+		program, err := midi.LookupInstrument(name)
+		if err != nil {
+			return nil, fmt.Errorf("author %s: %w", author, err)
+		}
+		instruments[author] = program
+	}
+
+	return instruments, nil
+}
@@ -0,0 +1,43 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTruncated is returned when a file or chunk ends before its declared length.
+	ErrTruncated = errors.New("unexpected end of data")
+
+	// ErrInvalidHeader is returned when the MThd chunk is missing or malformed.
+	ErrInvalidHeader = errors.New("invalid header chunk")
+
+	// ErrInvalidVarLen is returned when a variable-length quantity exceeds 4 bytes.
+	ErrInvalidVarLen = errors.New("invalid variable-length quantity")
+
+	// ErrInvalidStatus is returned when an event has an unknown or unsupported status byte.
+	ErrInvalidStatus = errors.New("invalid status byte")
+
+	// ErrNoRunningStatus is returned when a data byte appears before any status byte.
+	ErrNoRunningStatus = errors.New("data byte without running status")
+
+	// ErrMissingEndOfTrack is returned when a track chunk does not end with an End of Track event.
+	ErrMissingEndOfTrack = errors.New("missing end of track event")
+)
+
+// ParseError describes a failure while parsing a chunk of a Standard MIDI File.
+type ParseError struct {
+	Chunk  string
+	Offset int
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s chunk at offset %d: %v", e.Chunk, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error so callers can use errors.Is.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
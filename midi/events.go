@@ -50,3 +50,106 @@ func BPMToMicrosecondsPerQuarter(bpm int) uint32 {
 	}
 	return 60000000 / uint32(bpm)
 }
+
+// MetaEvent creates a meta event of the given type carrying data.
+func MetaEvent(metaType byte, data []byte) []byte {
+	length := EncodeVarLen(uint32(len(data)))
+	event := make([]byte, 0, 2+len(length)+len(data))
+	event = append(event, 0xFF, metaType)
+	event = append(event, length...)
+	return append(event, data...)
+}
+
+// SysEx creates a System Exclusive event. status must be 0xF0 for a complete
+// or initial packet, or 0xF7 for a continuation or escape sequence.
+func SysEx(status byte, data []byte) []byte {
+	if status != 0xF7 {
+		status = 0xF0
+	}
+	length := EncodeVarLen(uint32(len(data)))
+	event := make([]byte, 0, 1+len(length)+len(data))
+	event = append(event, status)
+	event = append(event, length...)
+	return append(event, data...)
+}
+
+// ProgramChange creates a Program Change event selecting an instrument (0-127).
+func ProgramChange(channel, program byte) []byte {
+	if channel > 15 {
+		channel = 15
+	}
+	if program > 127 {
+		program = 127
+	}
+	return []byte{0xC0 | channel, program}
+}
+
+// Common Control Change controller numbers.
+const (
+	CCBankSelect byte = 0
+	CCModulation byte = 1
+	CCVolume     byte = 7
+	CCPan        byte = 10
+	CCExpression byte = 11
+	CCSustain    byte = 64
+	CCReverb     byte = 91
+	CCChorus     byte = 93
+)
+
+// PitchBendCenter is the pitch bend value for no bend.
+const PitchBendCenter = 0
+
+// ControlChange creates a Control Change event setting controller to value.
+func ControlChange(channel, controller, value byte) []byte {
+	if channel > 15 {
+		channel = 15
+	}
+	if controller > 127 {
+		controller = 127
+	}
+	if value > 127 {
+		value = 127
+	}
+	return []byte{0xB0 | channel, controller, value}
+}
+
+// PitchBend creates a Pitch Bend event. value ranges from -8192 (full bend
+// down) to 8191 (full bend up), with 0 meaning no bend.
+func PitchBend(channel byte, value int) []byte {
+	if channel > 15 {
+		channel = 15
+	}
+	if value < -8192 {
+		value = -8192
+	}
+	if value > 8191 {
+		value = 8191
+	}
+	raw := uint16(value + 8192)
+	return []byte{0xE0 | channel, byte(raw & 0x7F), byte(raw >> 7)}
+}
+
+// ChannelPressure creates a Channel Pressure (aftertouch) event.
+func ChannelPressure(channel, pressure byte) []byte {
+	if channel > 15 {
+		channel = 15
+	}
+	if pressure > 127 {
+		pressure = 127
+	}
+	return []byte{0xD0 | channel, pressure}
+}
+
+// PolyAftertouch creates a Polyphonic Key Pressure event for a single note.
+func PolyAftertouch(channel, note, pressure byte) []byte {
+	if channel > 15 {
+		channel = 15
+	}
+	if note > 127 {
+		note = 127
+	}
+	if pressure > 127 {
+		pressure = 127
+	}
+	return []byte{0xA0 | channel, note, pressure}
+}
@@ -0,0 +1,54 @@
+package midi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChannelVoiceEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    []byte
+		expected []byte
+	}{
+		{name: "control change", event: ControlChange(2, CCPan, 32), expected: []byte{0xB2, 0x0A, 0x20}},
+		{name: "control change clamps", event: ControlChange(20, 200, 200), expected: []byte{0xBF, 0x7F, 0x7F}},
+		{name: "pitch bend center", event: PitchBend(0, PitchBendCenter), expected: []byte{0xE0, 0x00, 0x40}},
+		{name: "pitch bend down", event: PitchBend(1, -8192), expected: []byte{0xE1, 0x00, 0x00}},
+		{name: "pitch bend up clamps", event: PitchBend(1, 10000), expected: []byte{0xE1, 0x7F, 0x7F}},
+		{name: "channel pressure", event: ChannelPressure(3, 90), expected: []byte{0xD3, 0x5A}},
+		{name: "poly aftertouch", event: PolyAftertouch(4, 60, 255), expected: []byte{0xA4, 0x3C, 0x7F}},
+		{name: "program change", event: ProgramChange(9, 130), expected: []byte{0xC9, 0x7F}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Equal(tt.event, tt.expected) {
+				t.Errorf("got % X, want % X", tt.event, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMetaEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    []byte
+		expected []byte
+	}{
+		{name: "track name", event: TrackName("git2midi"), expected: append([]byte{0xFF, 0x03, 0x08}, "git2midi"...)},
+		{name: "lyric", event: Lyric("fix"), expected: []byte{0xFF, 0x05, 0x03, 'f', 'i', 'x'}},
+		{name: "time signature 6/8", event: TimeSignature(6, 8), expected: []byte{0xFF, 0x58, 0x04, 0x06, 0x03, 0x18, 0x08}},
+		{name: "key signature E major", event: KeySignature(4, false), expected: []byte{0xFF, 0x59, 0x02, 0x04, 0x00}},
+		{name: "key signature C minor", event: KeySignature(-3, true), expected: []byte{0xFF, 0x59, 0x02, 0xFD, 0x01}},
+		{name: "key signature clamps", event: KeySignature(-9, false), expected: []byte{0xFF, 0x59, 0x02, 0xF9, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Equal(tt.event, tt.expected) {
+				t.Errorf("got % X, want % X", tt.event, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package midi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InstrumentNames lists the 128 General MIDI Level 1 instruments by program number.
+var InstrumentNames = [128]string{
+	// Piano
+	"Acoustic Grand Piano", "Bright Acoustic Piano", "Electric Grand Piano", "Honky-tonk Piano",
+	"Electric Piano 1", "Electric Piano 2", "Harpsichord", "Clavinet",
+	// Chromatic Percussion
+	"Celesta", "Glockenspiel", "Music Box", "Vibraphone",
+	"Marimba", "Xylophone", "Tubular Bells", "Dulcimer",
+	// Organ
+	"Drawbar Organ", "Percussive Organ", "Rock Organ", "Church Organ",
+	"Reed Organ", "Accordion", "Harmonica", "Tango Accordion",
+	// Guitar
+	"Acoustic Guitar (nylon)", "Acoustic Guitar (steel)", "Electric Guitar (jazz)", "Electric Guitar (clean)",
+	"Electric Guitar (muted)", "Overdriven Guitar", "Distortion Guitar", "Guitar Harmonics",
+	// Bass
+	"Acoustic Bass", "Electric Bass (finger)", "Electric Bass (pick)", "Fretless Bass",
+	"Slap Bass 1", "Slap Bass 2", "Synth Bass 1", "Synth Bass 2",
+	// Strings
+	"Violin", "Viola", "Cello", "Contrabass",
+	"Tremolo Strings", "Pizzicato Strings", "Orchestral Harp", "Timpani",
+	// Ensemble
+	"String Ensemble 1", "String Ensemble 2", "Synth Strings 1", "Synth Strings 2",
+	"Choir Aahs", "Voice Oohs", "Synth Choir", "Orchestra Hit",
+	// Brass
+	"Trumpet", "Trombone", "Tuba", "Muted Trumpet",
+	"French Horn", "Brass Section", "Synth Brass 1", "Synth Brass 2",
+	// Reed
+	"Soprano Sax", "Alto Sax", "Tenor Sax", "Baritone Sax",
+	"Oboe", "English Horn", "Bassoon", "Clarinet",
+	// Pipe
+	"Piccolo", "Flute", "Recorder", "Pan Flute",
+	"Blown Bottle", "Shakuhachi", "Whistle", "Ocarina",
+	// Synth Lead
+	"Lead 1 (square)", "Lead 2 (sawtooth)", "Lead 3 (calliope)", "Lead 4 (chiff)",
+	"Lead 5 (charang)", "Lead 6 (voice)", "Lead 7 (fifths)", "Lead 8 (bass + lead)",
+	// Synth Pad
+	"Pad 1 (new age)", "Pad 2 (warm)", "Pad 3 (polysynth)", "Pad 4 (choir)",
+	"Pad 5 (bowed)", "Pad 6 (metallic)", "Pad 7 (halo)", "Pad 8 (sweep)",
+	// Synth Effects
+	"FX 1 (rain)", "FX 2 (soundtrack)", "FX 3 (crystal)", "FX 4 (atmosphere)",
+	"FX 5 (brightness)", "FX 6 (goblins)", "FX 7 (echoes)", "FX 8 (sci-fi)",
+	// Ethnic
+	"Sitar", "Banjo", "Shamisen", "Koto",
+	"Kalimba", "Bagpipe", "Fiddle", "Shanai",
+	// Percussive
+	"Tinkle Bell", "Agogo", "Steel Drums", "Woodblock",
+	"Taiko Drum", "Melodic Tom", "Synth Drum", "Reverse Cymbal",
+	// Sound Effects
+	"Guitar Fret Noise", "Breath Noise", "Seashore", "Bird Tweet",
+	"Telephone Ring", "Helicopter", "Applause", "Gunshot",
+}
+
+// InstrumentFamilies lists the 16 General MIDI instrument families. Each
+// family covers eight consecutive program numbers.
+var InstrumentFamilies = [16]string{
+	"Piano", "Chromatic Percussion", "Organ", "Guitar",
+	"Bass", "Strings", "Ensemble", "Brass",
+	"Reed", "Pipe", "Synth Lead", "Synth Pad",
+	"Synth Effects", "Ethnic", "Percussive", "Sound Effects",
+}
+
+// ProgramName returns the General MIDI instrument name of a program number.
+func ProgramName(program byte) string {
+	return InstrumentNames[program&0x7F]
+}
+
+// ProgramFamily returns the General MIDI instrument family of a program number.
+func ProgramFamily(program byte) string {
+	return InstrumentFamilies[(program&0x7F)/8]
+}
+
+// LookupInstrument finds a General MIDI program by number (0-127) or by name.
+// Names are matched case-insensitively, ignoring punctuation: an exact
+// instrument name wins, then a family name selects the family's first
+// instrument, and otherwise the first instrument containing every word of the
+// query is chosen (so "electric bass" selects "Electric Bass (finger)").
+func LookupInstrument(name string) (byte, error) {
+	query := strings.TrimSpace(name)
+	if program, err := strconv.Atoi(query); err == nil {
+		if program < 0 || program > 127 {
+			return 0, fmt.Errorf("instrument program %d out of range (0-127)", program)
+		}
+		return byte(program), nil
+	}
+
+	words := instrumentWords(query)
+	if len(words) == 0 {
+		return 0, fmt.Errorf("empty instrument name")
+	}
+	key := strings.Join(words, " ")
+
+	for program, instrument := range InstrumentNames {
+		if strings.Join(instrumentWords(instrument), " ") == key {
+			return byte(program), nil
+		}
+	}
+
+	for family, familyName := range InstrumentFamilies {
+		if strings.Join(instrumentWords(familyName), " ") == key {
+			return byte(family * 8), nil
+		}
+	}
+
+	for program, instrument := range InstrumentNames {
+		if containsWords(instrumentWords(instrument), words) {
+			return byte(program), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown instrument: %q", name)
+}
+
+// instrumentWords splits a name into lowercase alphanumeric words.
+func instrumentWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+}
+
+// containsWords reports whether every query word is a prefix of some word in words.
+func containsWords(words, query []string) bool {
+	for _, q := range query {
+		found := false
+		for _, w := range words {
+			if strings.HasPrefix(w, q) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package midi
+
+import "testing"
+
+func TestLookupInstrument(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    byte
+		wantErr bool
+	}{
+		{name: "exact name", query: "Acoustic Grand Piano", want: 0},
+		{name: "exact name with punctuation", query: "Acoustic Guitar (nylon)", want: 24},
+		{name: "case-insensitive", query: "VIOLIN", want: 40},
+		{name: "punctuation ignored", query: "honky tonk piano", want: 3},
+		{name: "number", query: "42", want: 42},
+		{name: "number with spaces", query: " 127 ", want: 127},
+		{name: "number too high", query: "128", wantErr: true},
+		{name: "negative number", query: "-1", wantErr: true},
+		{name: "family", query: "Strings", want: 40},
+		{name: "family lowercase", query: "synth lead", want: 80},
+		{name: "exact name before family", query: "Timpani", want: 47},
+		{name: "word prefixes", query: "electric bass", want: 33},
+		{name: "single prefix", query: "string", want: 44},
+		{name: "ambiguous prefix takes lowest program", query: "electric", want: 2},
+		{name: "prefixes in any order", query: "2 piano", want: 5},
+		{name: "unknown", query: "kazoo", wantErr: true},
+		{name: "partial mismatch", query: "electric kazoo", wantErr: true},
+		{name: "empty", query: "", wantErr: true},
+		{name: "only punctuation", query: "()", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupInstrument(tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("LookupInstrument(%q) = %d, want error", tt.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LookupInstrument(%q) failed: %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("LookupInstrument(%q) = %d (%s), want %d (%s)",
+					tt.query, got, ProgramName(got), tt.want, ProgramName(tt.want))
+			}
+		})
+	}
+}
+
+func TestProgramName(t *testing.T) {
+	tests := []struct {
+		program byte
+		name    string
+		family  string
+	}{
+		{program: 0, name: "Acoustic Grand Piano", family: "Piano"},
+		{program: 33, name: "Electric Bass (finger)", family: "Bass"},
+		{program: 127, name: "Gunshot", family: "Sound Effects"},
+		{program: 128, name: "Acoustic Grand Piano", family: "Piano"},
+	}
+
+	for _, tt := range tests {
+		if got := ProgramName(tt.program); got != tt.name {
+			t.Errorf("ProgramName(%d) = %q, want %q", tt.program, got, tt.name)
+		}
+		if got := ProgramFamily(tt.program); got != tt.family {
+			t.Errorf("ProgramFamily(%d) = %q, want %q", tt.program, got, tt.family)
+		}
+	}
+
+	// Every instrument name looks up its own program.
+	for program, name := range InstrumentNames {
+		if got, err := LookupInstrument(name); err != nil || got != byte(program) {
+			t.Errorf("LookupInstrument(%q) = %d, %v; want %d", name, got, err, program)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package midi
+
+// Meta event types.
+const (
+	MetaText              byte = 0x01
+	MetaCopyright         byte = 0x02
+	MetaTrackName         byte = 0x03
+	MetaInstrumentName    byte = 0x04
+	MetaLyric             byte = 0x05
+	MetaMarker            byte = 0x06
+	MetaCuePoint          byte = 0x07
+	MetaEndOfTrack        byte = 0x2F
+	MetaSetTempo          byte = 0x51
+	MetaTimeSignature     byte = 0x58
+	MetaKeySignature      byte = 0x59
+	MetaSequencerSpecific byte = 0x7F
+)
+
+// Text creates a Text meta event.
+func Text(text string) []byte {
+	return MetaEvent(MetaText, []byte(text))
+}
+
+// Copyright creates a Copyright Notice meta event.
+func Copyright(text string) []byte {
+	return MetaEvent(MetaCopyright, []byte(text))
+}
+
+// TrackName creates a Sequence/Track Name meta event. In the first track of
+// a file it names the whole sequence.
+func TrackName(name string) []byte {
+	return MetaEvent(MetaTrackName, []byte(name))
+}
+
+// InstrumentName creates an Instrument Name meta event.
+func InstrumentName(name string) []byte {
+	return MetaEvent(MetaInstrumentName, []byte(name))
+}
+
+// Lyric creates a Lyric meta event.
+func Lyric(text string) []byte {
+	return MetaEvent(MetaLyric, []byte(text))
+}
+
+// Marker creates a Marker meta event.
+func Marker(text string) []byte {
+	return MetaEvent(MetaMarker, []byte(text))
+}
+
+// CuePoint creates a Cue Point meta event.
+func CuePoint(text string) []byte {
+	return MetaEvent(MetaCuePoint, []byte(text))
+}
+
+// TimeSignature creates a Time Signature meta event for numerator/denominator
+// time. denominator must be a power of two; other values are rounded down to
+// the nearest power of two, and 0 is treated as 1. The metronome clicks once
+// per quarter note.
+func TimeSignature(numerator, denominator byte) []byte {
+	power := byte(0)
+	for d := denominator; d > 1; d >>= 1 {
+		power++
+	}
+	return MetaEvent(MetaTimeSignature, []byte{numerator, power, 24, 8})
+}
+
+// KeySignature creates a Key Signature meta event. sharps is the number of
+// sharps (positive) or flats (negative), from -7 to 7.
+func KeySignature(sharps int, minor bool) []byte {
+	if sharps < -7 {
+		sharps = -7
+	}
+	if sharps > 7 {
+		sharps = 7
+	}
+	mode := byte(0)
+	if minor {
+		mode = 1
+	}
+	return MetaEvent(MetaKeySignature, []byte{byte(int8(sharps)), mode})
+}
+
+// SequencerSpecific creates a Sequencer-Specific meta event carrying data.
+func SequencerSpecific(data []byte) []byte {
+	return MetaEvent(MetaSequencerSpecific, data)
+}
+
+// ParseMeta splits a meta event into its type and data. ok is false if event
+// is not a well-formed meta event.
+func ParseMeta(event []byte) (metaType byte, data []byte, ok bool) {
+	if len(event) < 3 || event[0] != 0xFF {
+		return 0, nil, false
+	}
+	length, n, err := readVarLen(event[2:])
+	if err != nil || uint32(len(event)-2-n) < length {
+		return 0, nil, false
+	}
+	start := 2 + n
+	return event[1], event[start : start+int(length)], true
+}
@@ -0,0 +1,213 @@
+package midi
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// ReadFile reads and parses the Standard MIDI File at the specified path.
+func ReadFile(filename string) (*Writer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Read parses a Standard MIDI File from r.
+func Read(r io.Reader) (*Writer, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses a Standard MIDI File into a Writer holding its tracks.
+// Running status is expanded so every returned event carries its own status byte.
+// Chunks other than MThd and MTrk are skipped.
+func Parse(data []byte) (*Writer, error) {
+	if len(data) < 8 || string(data[0:4]) != "MThd" {
+		return nil, &ParseError{Chunk: "MThd", Offset: 0, Err: ErrInvalidHeader}
+	}
+
+	headerLength := int(readUint32(data[4:8]))
+	if headerLength < 6 {
+		return nil, &ParseError{Chunk: "MThd", Offset: 4, Err: ErrInvalidHeader}
+	}
+	if len(data) < 8+headerLength {
+		return nil, &ParseError{Chunk: "MThd", Offset: 8, Err: ErrTruncated}
+	}
+
+	format := readUint16(data[8:10])
+	numTracks := int(readUint16(data[10:12]))
+	division := readUint16(data[12:14])
+
+	if format > 2 {
+		return nil, &ParseError{Chunk: "MThd", Offset: 8, Err: fmt.Errorf("%w: unsupported format %d", ErrInvalidHeader, format)}
+	}
+
+	writer := NewWriter(format, division)
+
+	pos := 8 + headerLength
+	for pos < len(data) {
+		if len(data)-pos < 8 {
+			return nil, &ParseError{Chunk: "chunk", Offset: pos, Err: ErrTruncated}
+		}
+
+		chunkType := string(data[pos : pos+4])
+		chunkLength := int(readUint32(data[pos+4 : pos+8]))
+		start := pos + 8
+		if len(data)-start < chunkLength {
+			return nil, &ParseError{Chunk: chunkType, Offset: pos, Err: ErrTruncated}
+		}
+
+		if chunkType == "MTrk" {
+			track, err := parseTrack(data[start:start+chunkLength], start)
+			if err != nil {
+				return nil, err
+			}
+			writer.AddTrack(track)
+		}
+
+		pos = start + chunkLength
+	}
+
+	if writer.TrackCount() < numTracks {
+		return nil, &ParseError{
+			Chunk:  "MTrk",
+			Offset: len(data),
+			Err:    fmt.Errorf("%w: expected %d tracks, found %d", ErrTruncated, numTracks, writer.TrackCount()),
+		}
+	}
+
+	return writer, nil
+}
+
+// parseTrack decodes the body of an MTrk chunk. base is the chunk's offset in
+// the file and is only used for error reporting.
+func parseTrack(data []byte, base int) (*Track, error) {
+	track := NewTrack()
+	fail := func(offset int, err error) error {
+		return &ParseError{Chunk: "MTrk", Offset: base + offset, Err: err}
+	}
+
+	var runningStatus byte
+	pos := 0
+	for pos < len(data) {
+		deltaTime, n, err := readVarLen(data[pos:])
+		if err != nil {
+			return nil, fail(pos, err)
+		}
+		pos += n
+
+		if pos >= len(data) {
+			return nil, fail(pos, ErrTruncated)
+		}
+
+		eventStart := pos
+		status := data[pos]
+		if status < 0x80 {
+			if runningStatus == 0 {
+				return nil, fail(pos, ErrNoRunningStatus)
+			}
+			status = runningStatus
+		} else {
+			pos++
+		}
+
+		switch {
+		case status < 0xF0:
+			runningStatus = status
+			size := channelEventDataLength(status)
+			if len(data)-pos < size {
+				return nil, fail(eventStart, ErrTruncated)
+			}
+			event := make([]byte, 0, size+1)
+			event = append(event, status)
+			for _, b := range data[pos : pos+size] {
+				if b > 0x7F {
+					return nil, fail(eventStart, fmt.Errorf("%w: data byte 0x%02X", ErrInvalidStatus, b))
+				}
+				event = append(event, b)
+			}
+			pos += size
+			track.AddEvent(deltaTime, event)
+
+		case status == 0xFF:
+			runningStatus = 0
+			if pos >= len(data) {
+				return nil, fail(eventStart, ErrTruncated)
+			}
+			metaType := data[pos]
+			pos++
+			length, n, err := readVarLen(data[pos:])
+			if err != nil {
+				return nil, fail(pos, err)
+			}
+			pos += n
+			if uint32(len(data)-pos) < length {
+				return nil, fail(eventStart, ErrTruncated)
+			}
+			event := MetaEvent(metaType, data[pos:pos+int(length)])
+			pos += int(length)
+			track.AddEvent(deltaTime, event)
+
+			if metaType == 0x2F {
+				return track, nil
+			}
+
+		case status == 0xF0 || status == 0xF7:
+			runningStatus = 0
+			length, n, err := readVarLen(data[pos:])
+			if err != nil {
+				return nil, fail(pos, err)
+			}
+			pos += n
+			if uint32(len(data)-pos) < length {
+				return nil, fail(eventStart, ErrTruncated)
+			}
+			track.AddEvent(deltaTime, SysEx(status, data[pos:pos+int(length)]))
+			pos += int(length)
+
+		default:
+			return nil, fail(eventStart, fmt.Errorf("%w: 0x%02X", ErrInvalidStatus, status))
+		}
+	}
+
+	return nil, fail(len(data), ErrMissingEndOfTrack)
+}
+
+// channelEventDataLength returns the number of data bytes following a channel voice status byte.
+func channelEventDataLength(status byte) int {
+	switch status & 0xF0 {
+	case 0xC0, 0xD0:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// readVarLen decodes a variable-length quantity, reporting truncated or
+// over-long encodings that DecodeVarLen tolerates.
+func readVarLen(data []byte) (uint32, int, error) {
+	for i := 0; i < len(data) && i < 4; i++ {
+		if data[i]&0x80 == 0 {
+			value, n := DecodeVarLen(data[:i+1])
+			return value, n, nil
+		}
+	}
+	if len(data) < 4 {
+		return 0, 0, ErrTruncated
+	}
+	return 0, 0, ErrInvalidVarLen
+}
+
+func readUint16(b []byte) uint16 {
+	return uint16(b[0])<<8 | uint16(b[1])
+}
+
+func readUint32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
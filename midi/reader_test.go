@@ -0,0 +1,158 @@
+package midi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	writer := NewWriter(1, 480)
+
+	track := NewTrack()
+	track.AddTempo(0, BPMToMicrosecondsPerQuarter(140))
+	track.AddNoteOn(0, 0, 60, 100)
+	track.AddNoteOff(120, 0, 60, 64)
+	track.AddEvent(10, SysEx(0xF0, []byte{0x7E, 0x7F, 0x09, 0x01, 0xF7}))
+	track.AddEndOfTrack(0)
+	writer.AddTrack(track)
+
+	second := NewTrack()
+	second.AddNoteOn(480, 3, 72, 90)
+	second.AddNoteOff(20000, 3, 72, 0)
+	second.AddEndOfTrack(0)
+	writer.AddTrack(second)
+
+	encoded := writer.Bytes()
+	parsed, err := Parse(encoded)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parsed.GetFormat() != 1 {
+		t.Errorf("format: got %d, want 1", parsed.GetFormat())
+	}
+	if parsed.GetDivision() != 480 {
+		t.Errorf("division: got %d, want 480", parsed.GetDivision())
+	}
+	if parsed.TrackCount() != 2 {
+		t.Fatalf("track count: got %d, want 2", parsed.TrackCount())
+	}
+	if !bytes.Equal(parsed.Bytes(), encoded) {
+		t.Errorf("re-encoded file differs from original")
+	}
+}
+
+func TestParseRunningStatus(t *testing.T) {
+	body := []byte{
+		0x00, 0x90, 0x3C, 0x64, // Note On C4
+		0x10, 0x40, 0x64, // running status Note On E4
+		0x10, 0x3C, 0x00, // running status Note On C4 velocity 0
+		0x00, 0xFF, 0x2F, 0x00,
+	}
+	data := buildFile(0, 1, 96, body)
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	events := parsed.Tracks()[0].Events()
+	expected := []TrackEvent{
+		{DeltaTime: 0, Data: []byte{0x90, 0x3C, 0x64}},
+		{DeltaTime: 16, Data: []byte{0x90, 0x40, 0x64}},
+		{DeltaTime: 16, Data: []byte{0x90, 0x3C, 0x00}},
+		{DeltaTime: 0, Data: []byte{0xFF, 0x2F, 0x00}},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("event count: got %d, want %d", len(events), len(expected))
+	}
+	for i := range expected {
+		if events[i].DeltaTime != expected[i].DeltaTime {
+			t.Errorf("event %d delta: got %d, want %d", i, events[i].DeltaTime, expected[i].DeltaTime)
+		}
+		if !bytes.Equal(events[i].Data, expected[i].Data) {
+			t.Errorf("event %d data: got % X, want % X", i, events[i].Data, expected[i].Data)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected error
+	}{
+		{
+			name:     "empty",
+			input:    nil,
+			expected: ErrInvalidHeader,
+		},
+		{
+			name:     "wrong magic",
+			input:    []byte("RIFF\x00\x00\x00\x06\x00\x00\x00\x01\x00\x60"),
+			expected: ErrInvalidHeader,
+		},
+		{
+			name:     "truncated header",
+			input:    []byte("MThd\x00\x00\x00\x06\x00\x00"),
+			expected: ErrTruncated,
+		},
+		{
+			name:     "missing track",
+			input:    buildFile(0, 2, 96, []byte{0x00, 0xFF, 0x2F, 0x00}),
+			expected: ErrTruncated,
+		},
+		{
+			name:     "truncated track chunk",
+			input:    buildFile(0, 1, 96, []byte{0x00, 0xFF, 0x2F, 0x00})[:24],
+			expected: ErrTruncated,
+		},
+		{
+			name:     "truncated event",
+			input:    buildFile(0, 1, 96, []byte{0x00, 0x90, 0x3C}),
+			expected: ErrTruncated,
+		},
+		{
+			name:     "data byte without status",
+			input:    buildFile(0, 1, 96, []byte{0x00, 0x3C, 0x64, 0x00, 0xFF, 0x2F, 0x00}),
+			expected: ErrNoRunningStatus,
+		},
+		{
+			name:     "invalid status",
+			input:    buildFile(0, 1, 96, []byte{0x00, 0xF8, 0x00, 0xFF, 0x2F, 0x00}),
+			expected: ErrInvalidStatus,
+		},
+		{
+			name:     "over-long delta time",
+			input:    buildFile(0, 1, 96, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0xFF, 0x2F, 0x00}),
+			expected: ErrInvalidVarLen,
+		},
+		{
+			name:     "missing end of track",
+			input:    buildFile(0, 1, 96, []byte{0x00, 0x90, 0x3C, 0x64}),
+			expected: ErrMissingEndOfTrack,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("error: got %v, want %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+// buildFile assembles a minimal SMF with a single MTrk chunk containing body.
+func buildFile(format, numTracks, division uint16, body []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("MThd")
+	buf.Write([]byte{0, 0, 0, 6, byte(format >> 8), byte(format), byte(numTracks >> 8), byte(numTracks), byte(division >> 8), byte(division)})
+	buf.WriteString("MTrk")
+	length := uint32(len(body))
+	buf.Write([]byte{byte(length >> 24), byte(length >> 16), byte(length >> 8), byte(length)})
+	buf.Write(body)
+	return buf.Bytes()
+}
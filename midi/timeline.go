@@ -0,0 +1,159 @@
+package midi
+
+import "sort"
+
+// TimedEvent represents a MIDI event at an absolute tick position.
+type TimedEvent struct {
+	Tick uint32
+	Data []byte
+}
+
+// Timeline collects events at absolute tick positions, in any order, and
+// compiles them into a Track with delta times.
+type Timeline struct {
+	events []TimedEvent
+	end    uint32
+}
+
+// NewTimeline creates a new empty timeline.
+func NewTimeline() *Timeline {
+	return &Timeline{
+		events: make([]TimedEvent, 0),
+	}
+}
+
+// Add adds an event at the specified absolute tick. End of Track events are
+// not stored; they only extend the timeline's end, since Compile always
+// terminates the track itself.
+func (tl *Timeline) Add(tick uint32, data []byte) {
+	if isEndOfTrack(data) {
+		tl.SetEnd(tick)
+		return
+	}
+	tl.events = append(tl.events, TimedEvent{
+		Tick: tick,
+		Data: data,
+	})
+	if tick > tl.end {
+		tl.end = tick
+	}
+}
+
+// AddNote adds a Note On at tick and the matching Note Off duration ticks later.
+func (tl *Timeline) AddNote(tick, duration uint32, channel, note, velocity byte) {
+	tl.Add(tick, NoteOn(channel, note, velocity))
+	tl.Add(tick+duration, NoteOff(channel, note, 64))
+}
+
+// AddProgramChange adds a Program Change event at the specified tick.
+func (tl *Timeline) AddProgramChange(tick uint32, channel, program byte) {
+	tl.Add(tick, ProgramChange(channel, program))
+}
+
+// AddControlChange adds a Control Change event at the specified tick.
+func (tl *Timeline) AddControlChange(tick uint32, channel, controller, value byte) {
+	tl.Add(tick, ControlChange(channel, controller, value))
+}
+
+// AddPitchBend adds a Pitch Bend event at the specified tick.
+func (tl *Timeline) AddPitchBend(tick uint32, channel byte, value int) {
+	tl.Add(tick, PitchBend(channel, value))
+}
+
+// AddTempo adds a Set Tempo meta event at the specified tick.
+func (tl *Timeline) AddTempo(tick uint32, tempo uint32) {
+	tl.Add(tick, SetTempo(tempo))
+}
+
+// Merge adds all events of other to the timeline, after the timeline's own
+// events at equal ticks, and extends the end to cover other's end.
+func (tl *Timeline) Merge(other *Timeline) {
+	tl.events = append(tl.events, other.events...)
+	tl.SetEnd(other.end)
+}
+
+// SetEnd extends the timeline so the End of Track event is placed no earlier
+// than tick. It never shortens the timeline below its last event.
+func (tl *Timeline) SetEnd(tick uint32) {
+	if tick > tl.end {
+		tl.end = tick
+	}
+}
+
+// End returns the tick at which the compiled track ends.
+func (tl *Timeline) End() uint32 {
+	return tl.end
+}
+
+// Len returns the number of events on the timeline.
+func (tl *Timeline) Len() int {
+	return len(tl.events)
+}
+
+// Events returns the timeline's events sorted into playback order.
+// Events at the same tick are ordered meta and system events first, then
+// note-offs, then other channel messages, then note-ons; otherwise insertion
+// order is preserved.
+func (tl *Timeline) Events() []TimedEvent {
+	sorted := make([]TimedEvent, len(tl.events))
+	copy(sorted, tl.events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Tick != sorted[j].Tick {
+			return sorted[i].Tick < sorted[j].Tick
+		}
+		return eventPriority(sorted[i].Data) < eventPriority(sorted[j].Data)
+	})
+	return sorted
+}
+
+// Compile converts the timeline into a Track with delta times, terminated by
+// an End of Track event.
+func (tl *Timeline) Compile() *Track {
+	track := NewTrack()
+
+	last := uint32(0)
+	for _, event := range tl.Events() {
+		track.AddEvent(event.Tick-last, event.Data)
+		last = event.Tick
+	}
+
+	track.AddEndOfTrack(tl.end - last)
+	return track
+}
+
+// eventPriority ranks events that share a tick so that notes released at a
+// tick are off before notes started at the same tick.
+func eventPriority(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+
+	switch status := data[0]; {
+	case status >= 0xF0:
+		return 0
+	case status&0xF0 == 0x80:
+		return 1
+	case status&0xF0 == 0x90 && len(data) > 2 && data[2] == 0:
+		return 1
+	case status&0xF0 == 0x90:
+		return 3
+	default:
+		return 2
+	}
+}
+
+func isEndOfTrack(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0xFF && data[1] == 0x2F
+}
+
+// Timeline converts the track's delta times into an absolute-time timeline,
+// which can be edited and compiled back into a track.
+func (t *Track) Timeline() *Timeline {
+	tl := NewTimeline()
+	tick := uint32(0)
+	for _, event := range t.events {
+		tick += event.DeltaTime
+		tl.Add(tick, event.Data)
+	}
+	return tl
+}
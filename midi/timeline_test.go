@@ -0,0 +1,73 @@
+package midi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTimelineCompile(t *testing.T) {
+	tl := NewTimeline()
+	tl.AddNote(240, 240, 0, 64, 100)
+	tl.AddNote(0, 240, 0, 60, 100)
+	tl.AddTempo(0, 500000)
+	tl.AddNote(0, 480, 1, 48, 80)
+
+	track := tl.Compile()
+	expected := []TrackEvent{
+		{DeltaTime: 0, Data: SetTempo(500000)},
+		{DeltaTime: 0, Data: NoteOn(0, 60, 100)},
+		{DeltaTime: 0, Data: NoteOn(1, 48, 80)},
+		{DeltaTime: 240, Data: NoteOff(0, 60, 64)},
+		{DeltaTime: 0, Data: NoteOn(0, 64, 100)},
+		{DeltaTime: 240, Data: NoteOff(0, 64, 64)},
+		{DeltaTime: 0, Data: NoteOff(1, 48, 64)},
+		{DeltaTime: 0, Data: EndOfTrack()},
+	}
+
+	events := track.Events()
+	if len(events) != len(expected) {
+		t.Fatalf("event count: got %d, want %d", len(events), len(expected))
+	}
+	for i := range expected {
+		if events[i].DeltaTime != expected[i].DeltaTime {
+			t.Errorf("event %d delta: got %d, want %d", i, events[i].DeltaTime, expected[i].DeltaTime)
+		}
+		if !bytes.Equal(events[i].Data, expected[i].Data) {
+			t.Errorf("event %d data: got % X, want % X", i, events[i].Data, expected[i].Data)
+		}
+	}
+}
+
+func TestTimelineEnd(t *testing.T) {
+	tl := NewTimeline()
+	tl.AddNote(0, 100, 0, 60, 100)
+	tl.SetEnd(960)
+	tl.SetEnd(10)
+	tl.Add(500, EndOfTrack())
+
+	if tl.End() != 960 {
+		t.Errorf("end: got %d, want 960", tl.End())
+	}
+	if tl.Len() != 2 {
+		t.Errorf("len: got %d, want 2", tl.Len())
+	}
+
+	events := tl.Compile().Events()
+	last := events[len(events)-1]
+	if !bytes.Equal(last.Data, EndOfTrack()) || last.DeltaTime != 860 {
+		t.Errorf("end of track: got delta %d data % X", last.DeltaTime, last.Data)
+	}
+}
+
+func TestTrackTimelineRoundTrip(t *testing.T) {
+	track := NewTrack()
+	track.AddTempo(0, 500000)
+	track.AddNoteOn(10, 0, 60, 100)
+	track.AddNoteOff(90, 0, 60, 64)
+	track.AddEndOfTrack(20)
+
+	compiled := track.Timeline().Compile()
+	if !bytes.Equal(compiled.Encode(), track.Encode()) {
+		t.Errorf("round trip changed track: got % X, want % X", compiled.Encode(), track.Encode())
+	}
+}
@@ -38,6 +38,31 @@ func (t *Track) AddNoteOff(deltaTime uint32, channel, note, velocity byte) {
 	t.AddEvent(deltaTime, NoteOff(channel, note, velocity))
 }
 
+// AddProgramChange adds a Program Change event with delta time.
+func (t *Track) AddProgramChange(deltaTime uint32, channel, program byte) {
+	t.AddEvent(deltaTime, ProgramChange(channel, program))
+}
+
+// AddControlChange adds a Control Change event with delta time.
+func (t *Track) AddControlChange(deltaTime uint32, channel, controller, value byte) {
+	t.AddEvent(deltaTime, ControlChange(channel, controller, value))
+}
+
+// AddPitchBend adds a Pitch Bend event with delta time.
+func (t *Track) AddPitchBend(deltaTime uint32, channel byte, value int) {
+	t.AddEvent(deltaTime, PitchBend(channel, value))
+}
+
+// AddChannelPressure adds a Channel Pressure event with delta time.
+func (t *Track) AddChannelPressure(deltaTime uint32, channel, pressure byte) {
+	t.AddEvent(deltaTime, ChannelPressure(channel, pressure))
+}
+
+// AddPolyAftertouch adds a Polyphonic Key Pressure event with delta time.
+func (t *Track) AddPolyAftertouch(deltaTime uint32, channel, note, pressure byte) {
+	t.AddEvent(deltaTime, PolyAftertouch(channel, note, pressure))
+}
+
 // AddTempo adds a Set Tempo meta event with delta time.
 func (t *Track) AddTempo(deltaTime uint32, tempo uint32) {
 	t.AddEvent(deltaTime, SetTempo(tempo))
@@ -71,6 +96,11 @@ func (t *Track) Encode() []byte {
 	return result
 }
 
+// Events returns the events in the track in playback order.
+func (t *Track) Events() []TrackEvent {
+	return t.events
+}
+
 // EventCount returns the number of events in the track.
 func (t *Track) EventCount() int {
 	return len(t.events)
@@ -1,6 +1,7 @@
 package midi
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 )
@@ -67,11 +68,31 @@ func (w *Writer) encodeHeader() []byte {
 	return header
 }
 
+// Bytes returns the complete encoded MIDI file.
+func (w *Writer) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(w.encodeHeader())
+	for _, track := range w.tracks {
+		buf.Write(track.Encode())
+	}
+	return buf.Bytes()
+}
+
 // GetDivision returns the ticks per quarter note.
 func (w *Writer) GetDivision() uint16 {
 	return w.division
 }
 
+// GetFormat returns the MIDI file format (0, 1 or 2).
+func (w *Writer) GetFormat() uint16 {
+	return w.format
+}
+
+// Tracks returns the tracks held by the writer.
+func (w *Writer) Tracks() []*Track {
+	return w.tracks
+}
+
 // TrackCount returns the number of tracks in the writer.
 func (w *Writer) TrackCount() int {
 	return len(w.tracks)
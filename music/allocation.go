@@ -0,0 +1,164 @@
+package music
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/klejdi94/git2midi/git"
+)
+
+// Allocation selects how authors are assigned to voices in per-author mode.
+type Allocation int
+
+const (
+	// AllocationTop gives the most active authors a voice each and groups
+	// everyone else into a single "Others" voice.
+	AllocationTop Allocation = iota
+	// AllocationHash hashes authors onto the available channels; authors
+	// sharing a channel share its voice.
+	AllocationHash
+)
+
+// othersName names the voice shared by the authors without one of their own.
+const othersName = "Others"
+
+// hashPrograms are the General MIDI programs of hashed voices, one per
+// channel, chosen from different families so voices stay distinguishable.
+var hashPrograms = []byte{
+	0,   // Acoustic Grand Piano
+	73,  // Flute
+	24,  // Acoustic Guitar (nylon)
+	40,  // Violin
+	11,  // Vibraphone
+	56,  // Trumpet
+	68,  // Oboe
+	46,  // Orchestral Harp
+	19,  // Church Organ
+	65,  // Alto Sax
+	71,  // Clarinet
+	13,  // Xylophone
+	42,  // Cello
+	105, // Banjo
+	80,  // Lead 1 (square)
+	4,   // Electric Piano 1
+}
+
+// Voice is one melodic track of a per-author or lanes composition. Authors
+// lists the authors a per-author voice plays, Lanes the graph lanes a lanes
+// voice plays.
+type Voice struct {
+	Name    string
+	Channel byte
+	Program byte
+	Pan     byte
+	Authors []string
+	Lanes   []int
+}
+
+// Voices returns the voices of the last per-author or lanes composition
+// generated.
+func (g *Generator) Voices() []Voice {
+	return g.voices
+}
+
+// allocateVoices assigns every author of the commits to a voice using the
+// configured allocation.
+func (g *Generator) allocateVoices(commits []git.Commit) []Voice {
+	// Co-authors need a voice too, so their commits count as well.
+	counts := make(map[string]int)
+	for _, commit := range commits {
+		counts[commit.Author]++
+		for _, coAuthor := range commit.CoAuthors {
+			if coAuthor.Name != commit.Author {
+				counts[coAuthor.Name]++
+			}
+		}
+	}
+
+	// Most active authors first; ties by name for a stable order.
+	authors := make([]string, 0, len(counts))
+	for author := range counts {
+		authors = append(authors, author)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if counts[authors[i]] != counts[authors[j]] {
+			return counts[authors[i]] > counts[authors[j]]
+		}
+		return authors[i] < authors[j]
+	})
+
+	channels := g.melodicChannels()
+
+	var voices []Voice
+	if g.config.Allocation == AllocationHash {
+		voices = hashVoices(authors, channels)
+	} else {
+		voices = g.topVoices(authors, channels)
+	}
+
+	for i := range voices {
+		voices[i].Pan = spreadPan(i, len(voices))
+	}
+	return voices
+}
+
+// topVoices gives each of the first authors a voice of their own, up to the
+// configured number of voices or one less than the channels available, and
+// puts the rest on a shared Others voice.
+func (g *Generator) topVoices(authors []string, channels []byte) []Voice {
+	dedicated := len(authors)
+	if dedicated > len(channels) {
+		dedicated = len(channels) - 1
+	}
+	if g.config.Voices > 0 && g.config.Voices < dedicated {
+		dedicated = g.config.Voices
+	}
+
+	voices := make([]Voice, 0, dedicated+1)
+	for i, author := range authors[:dedicated] {
+		voices = append(voices, Voice{
+			Name:    author,
+			Channel: channels[i],
+			Program: g.authorInstrument(author),
+			Authors: []string{author},
+		})
+	}
+
+	if others := authors[dedicated:]; len(others) > 0 {
+		voices = append(voices, Voice{
+			Name:    othersName,
+			Channel: channels[dedicated],
+			Program: g.config.Instrument,
+			Authors: others,
+		})
+	}
+	return voices
+}
+
+// hashVoices hashes each author onto a channel, giving every channel in use
+// its own instrument.
+func hashVoices(authors []string, channels []byte) []Voice {
+	byChannel := make(map[int][]string)
+	for _, author := range authors {
+		h := fnv.New32a()
+		h.Write([]byte(author))
+		index := int(h.Sum32() % uint32(len(channels)))
+		byChannel[index] = append(byChannel[index], author)
+	}
+
+	voices := make([]Voice, 0, len(byChannel))
+	for index, channel := range channels {
+		members := byChannel[index]
+		if len(members) == 0 {
+			continue
+		}
+		voices = append(voices, Voice{
+			Name:    strings.Join(members, ", "),
+			Channel: channel,
+			Program: hashPrograms[index%len(hashPrograms)],
+			Authors: members,
+		})
+	}
+	return voices
+}
@@ -0,0 +1,81 @@
+package music
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+)
+
+// authorCommits returns commits by n authors, each author making one commit
+// fewer than the one before.
+func authorCommits(n int) []git.Commit {
+	var commits []git.Commit
+	for i := 0; i < n; i++ {
+		for j := 0; j <= n-i; j++ {
+			commits = append(commits, git.Commit{
+				Hash:    fmt.Sprintf("%07d", len(commits)),
+				Author:  fmt.Sprintf("author%02d", i),
+				Message: "change",
+			})
+		}
+	}
+	return commits
+}
+
+func TestAllocateVoices(t *testing.T) {
+	tests := []struct {
+		name       string
+		authors    int
+		allocation Allocation
+		voices     int
+		wantVoices int
+		wantOthers int
+	}{
+		{name: "few authors", authors: 3, wantVoices: 3},
+		{name: "top beyond channels", authors: 40, wantVoices: 15, wantOthers: 26},
+		{name: "top capped", authors: 10, voices: 4, wantVoices: 5, wantOthers: 6},
+		{name: "hash", authors: 40, allocation: AllocationHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 120, Mode: ModePerAuthor, Scale: ScaleMajor, Allocation: tt.allocation, Voices: tt.voices})
+			writer, err := gen.Generate(authorCommits(tt.authors))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			voices := gen.Voices()
+			if writer.TrackCount() != len(voices)+1 {
+				t.Errorf("tracks: got %d, want %d voices plus the conductor", writer.TrackCount(), len(voices))
+			}
+
+			authors := 0
+			channels := make(map[byte]bool)
+			for _, voice := range voices {
+				if voice.Channel == drumChannel || channels[voice.Channel] {
+					t.Errorf("voice %q: channel %d reused or reserved", voice.Name, voice.Channel)
+				}
+				channels[voice.Channel] = true
+				authors += len(voice.Authors)
+			}
+			if authors != tt.authors {
+				t.Errorf("authors: got %d, want %d", authors, tt.authors)
+			}
+
+			if tt.wantVoices == 0 {
+				return
+			}
+			if len(voices) != tt.wantVoices {
+				t.Fatalf("voices: got %d, want %d", len(voices), tt.wantVoices)
+			}
+			if voices[0].Name != "author00" {
+				t.Errorf("first voice: got %q, want the most active author", voices[0].Name)
+			}
+			if last := voices[len(voices)-1]; tt.wantOthers > 0 && (last.Name != othersName || len(last.Authors) != tt.wantOthers) {
+				t.Errorf("others: got %q with %d authors, want %d", last.Name, len(last.Authors), tt.wantOthers)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package music
+
+import (
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// addCoAuthors plays a commit's note on the voice of each of its co-authors
+// as well, every further voice CoAuthorInterval scale steps above the last,
+// so pair-programmed commits sound as a unison or a chord. Voices already
+// playing the commit are skipped.
+func (g *Generator) addCoAuthors(parts []*midi.Timeline, authorVoice map[string]int, commit git.Commit, index int, note Note) {
+	played := map[int]bool{authorVoice[commit.Author]: true}
+
+	steps := 0
+	for _, coAuthor := range commit.CoAuthors {
+		voice, ok := authorVoice[coAuthor.Name]
+		if !ok || played[voice] {
+			continue
+		}
+		played[voice] = true
+
+		steps += g.config.CoAuthorInterval
+		channel := g.mapper.Channel(commit, index, g.voices[voice].Channel)
+		parts[voice].AddNote(note.Tick, note.Duration, channel, g.scaleStep(note.Pitch, steps), note.Velocity)
+	}
+}
+
+// scaleStep returns the note steps degrees of the configured scale above
+// pitch, or below it for negative steps. Pitches outside the scale count
+// from the next scale note up.
+func (g *Generator) scaleStep(pitch byte, steps int) byte {
+	if steps == 0 {
+		return pitch
+	}
+
+	notes := g.config.Scale.Notes(g.config.Root, 0, 127)
+	i := 0
+	for i < len(notes) && notes[i] < pitch {
+		i++
+	}
+	i += steps
+	if i >= len(notes) {
+		i = len(notes) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return notes[i]
+}
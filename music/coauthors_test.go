@@ -0,0 +1,85 @@
+package music
+
+import (
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// noteOns returns the pitches of the note-ons in a track.
+func noteOns(track *midi.Track) []byte {
+	var pitches []byte
+	for _, event := range track.Events() {
+		if event.Data[0]&0xF0 == 0x90 && event.Data[2] > 0 {
+			pitches = append(pitches, event.Data[1])
+		}
+	}
+	return pitches
+}
+
+func TestCoAuthors(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Author: "Alice", Message: "Solo work"},
+		{Hash: "b2", Author: "Bob", Message: "Solo work"},
+		{Hash: "c3", Author: "Alice", Message: "Pair work", CoAuthors: []git.Identity{{Name: "Bob"}, {Name: "Alice"}}},
+		{Hash: "d4", Author: "Alice", Message: "Pair work", CoAuthors: []git.Identity{{Name: "Carol", Email: "carol@example.com"}}},
+	}
+
+	tests := []struct {
+		name     string
+		interval int
+	}{
+		{name: "unison", interval: 0},
+		{name: "third", interval: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 120, Mode: ModePerAuthor, Scale: ScaleMajor, CoAuthorInterval: tt.interval})
+			writer, err := gen.Generate(commits)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Voices by activity: Alice, Bob, Carol.
+			if len(gen.Voices()) != 3 {
+				t.Fatalf("voices: got %d, want 3", len(gen.Voices()))
+			}
+			alice := noteOns(writer.Tracks()[1])
+			bob := noteOns(writer.Tracks()[2])
+			carol := noteOns(writer.Tracks()[3])
+
+			if len(alice) != 3 || len(bob) != 2 || len(carol) != 1 {
+				t.Fatalf("notes per voice: got %d, %d, %d, want 3, 2, 1", len(alice), len(bob), len(carol))
+			}
+			if want := gen.scaleStep(alice[1], tt.interval); bob[1] != want {
+				t.Errorf("Bob's co-authored note: got %d, want %d", bob[1], want)
+			}
+			if want := gen.scaleStep(alice[2], tt.interval); carol[0] != want {
+				t.Errorf("Carol's co-authored note: got %d, want %d", carol[0], want)
+			}
+		})
+	}
+}
+
+func TestScaleStep(t *testing.T) {
+	gen := NewGenerator(&Config{Scale: ScaleMajor})
+	tests := []struct {
+		pitch byte
+		steps int
+		want  byte
+	}{
+		{pitch: 60, steps: 0, want: 60},
+		{pitch: 60, steps: 2, want: 64},
+		{pitch: 62, steps: 2, want: 65},
+		{pitch: 61, steps: 1, want: 64},
+		{pitch: 127, steps: 4, want: 127},
+	}
+
+	for _, tt := range tests {
+		if got := gen.scaleStep(tt.pitch, tt.steps); got != tt.want {
+			t.Errorf("scaleStep(%d, %d): got %d, want %d", tt.pitch, tt.steps, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package music
+
+import (
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// Contour is the melodic gesture a commit type plays on its note.
+type Contour int
+
+const (
+	// ContourNone plays the note as is.
+	ContourNone Contour = iota
+	// ContourRise steps up a third halfway through the note.
+	ContourRise
+	// ContourResolve falls to the tonic halfway through the note.
+	ContourResolve
+)
+
+// riseSteps is the number of scale steps a rising note climbs (a third).
+const riseSteps = 2
+
+// DefaultKeyChange is the number of semitones the key moves at each
+// breaking change by default: up a fifth.
+const DefaultKeyChange = 7
+
+// TypeStyle is how the commits of one Conventional Commits type are played.
+type TypeStyle struct {
+	// Octave moves the type's notes by octaves into a register of its own.
+	Octave int
+	// Contour is the gesture played on the type's notes.
+	Contour Contour
+}
+
+// TypeMapping plays commits by their Conventional Commits type.
+type TypeMapping struct {
+	// Styles maps lower-case commit types ("feat", "fix") to their style.
+	// Commits of other types, and subjects not following the convention,
+	// play as usual.
+	Styles map[string]TypeStyle
+
+	// KeyChange is the number of semitones the key moves at each breaking
+	// change, for the melody, harmony and key signature alike (0 = none).
+	KeyChange int
+}
+
+// DefaultTypeMapping returns the built-in mapping: features and
+// performance work rise, fixes and reverts resolve to the tonic,
+// documentation, tests and style sit an octave up, refactoring and
+// maintenance an octave down, and breaking changes move the key up a fifth.
+func DefaultTypeMapping() *TypeMapping {
+	return &TypeMapping{
+		Styles: map[string]TypeStyle{
+			"feat":     {Contour: ContourRise},
+			"perf":     {Contour: ContourRise},
+			"fix":      {Contour: ContourResolve},
+			"revert":   {Contour: ContourResolve},
+			"docs":     {Octave: 1},
+			"test":     {Octave: 1},
+			"style":    {Octave: 1},
+			"refactor": {Octave: -1},
+			"build":    {Octave: -1},
+			"ci":       {Octave: -1},
+			"chore":    {Octave: -1},
+		},
+		KeyChange: DefaultKeyChange,
+	}
+}
+
+// typeStyle returns the style of the commit's type, if it has one.
+func (g *Generator) typeStyle(commit git.Commit) (TypeStyle, bool) {
+	if g.config.Types == nil || commit.Type == "" {
+		return TypeStyle{}, false
+	}
+	style, ok := g.config.Types.Styles[commit.Type]
+	return style, ok
+}
+
+// modulations returns the transposition in semitones of every commit: each
+// breaking change moves the key by KeyChange from that commit on. It returns
+// nil when the key never changes.
+func (g *Generator) modulations(commits []git.Commit) []int {
+	if !g.changesKey() {
+		return nil
+	}
+
+	keys := make([]int, len(commits))
+	shift, changed := 0, false
+	for i, commit := range commits {
+		shift = g.modulate(shift, commit)
+		changed = changed || commit.Breaking
+		keys[i] = shift
+	}
+	if !changed {
+		return nil
+	}
+	return keys
+}
+
+// changesKey reports whether breaking changes move the key.
+func (g *Generator) changesKey() bool {
+	return g.config.Types != nil && g.config.Types.KeyChange%12 != 0
+}
+
+// modulate returns the transposition from a commit on, given the one before
+// it: a breaking change moves the key by KeyChange.
+func (g *Generator) modulate(shift int, commit git.Commit) int {
+	if commit.Breaking {
+		return wrapShift(shift + g.config.Types.KeyChange)
+	}
+	return shift
+}
+
+// wrapShift keeps a transposition within a tritone either way, so the
+// melody stays in its register as the key moves.
+func wrapShift(shift int) int {
+	shift = (shift%12 + 12) % 12
+	if shift > 6 {
+		shift -= 12
+	}
+	return shift
+}
+
+// keyShift returns the transposition of the commit at index.
+func (g *Generator) keyShift(index int) int {
+	if g.keys == nil {
+		return 0
+	}
+	return g.keys[index]
+}
+
+// keyRoot returns the root pitch class of the key at the commit at index.
+func (g *Generator) keyRoot(index int) int {
+	return ((g.config.Root+g.keyShift(index))%12 + 12) % 12
+}
+
+// addKeyChanges adds a key signature at every commit that changes key.
+func (g *Generator) addKeyChanges(timeline *midi.Timeline, schedule []scheduledNote) {
+	for i := 1; i < len(g.keys); i++ {
+		g.addKeyChange(timeline, i, schedule[i])
+	}
+}
+
+// addKeyChange adds a key signature at the commit at index if it changes key.
+func (g *Generator) addKeyChange(timeline *midi.Timeline, index int, slot scheduledNote) {
+	if index > 0 && index < len(g.keys) && g.keys[index] != g.keys[index-1] {
+		sharps, minor := g.config.Scale.KeySignature(g.keyRoot(index))
+		timeline.Add(slot.tick, midi.KeySignature(sharps, minor))
+	}
+}
+
+// playNote plays a commit's note with a contour: for the second half of the
+// note, a rise steps up a third and a resolution falls to the tonic at or
+// below the note, both in the commit's key.
+func (g *Generator) playNote(timeline *midi.Timeline, note Note, contour Contour, index int) {
+	shift := g.keyShift(index)
+
+	target := note.Pitch
+	switch contour {
+	case ContourRise:
+		target = transpose(g.scaleStep(transpose(note.Pitch, -shift), riseSteps), shift)
+	case ContourResolve:
+		target = chordTone(pitchAbove(g.keyRoot(index), int(note.Pitch)-11))
+	}
+
+	if target == note.Pitch || note.Duration < 2 {
+		timeline.AddNote(note.Tick, note.Duration, note.Channel, note.Pitch, note.Velocity)
+		return
+	}
+
+	first := note.Duration / 2
+	timeline.AddNote(note.Tick, first, note.Channel, note.Pitch, note.Velocity)
+	timeline.AddNote(note.Tick+first, note.Duration-first, note.Channel, target, note.Velocity)
+}
+
+// transpose moves a pitch by semitones, clamped to the MIDI note range.
+func transpose(pitch byte, semitones int) byte {
+	return chordTone(int(pitch) + semitones)
+}
@@ -0,0 +1,77 @@
+package music
+
+import (
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// typedPitches generates commits in one track and returns its note-ons.
+func typedPitches(t *testing.T, commits []git.Commit, types *TypeMapping) []byte {
+	t.Helper()
+	gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 480, Scale: ScaleMajor, Types: types})
+	writer, err := gen.Generate(commits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return noteOns(writer.Tracks()[0])
+}
+
+func TestTypeStyles(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Message: "feat: add lanes", Type: "feat"},
+		{Hash: "b2", Message: "fix: close packs", Type: "fix"},
+		{Hash: "c3", Message: "docs: explain lanes", Type: "docs"},
+		{Hash: "d4", Message: "Update README"},
+	}
+
+	plain := typedPitches(t, commits, nil)
+	if len(plain) != len(commits) {
+		t.Fatalf("plain notes: got %d, want %d", len(plain), len(commits))
+	}
+
+	gen := NewGenerator(&Config{Scale: ScaleMajor})
+	var want []byte
+	want = append(want, plain[0], gen.scaleStep(plain[0], riseSteps))
+	want = append(want, plain[1])
+	if tonic := byte(pitchAbove(0, int(plain[1])-11)); tonic != plain[1] {
+		want = append(want, tonic)
+	}
+	want = append(want, plain[2]+12, plain[3])
+
+	if got := typedPitches(t, commits, DefaultTypeMapping()); string(got) != string(want) {
+		t.Errorf("notes: got %v, want %v", got, want)
+	}
+}
+
+func TestKeyChange(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Message: "Initial commit"},
+		{Hash: "b2", Message: "refactor!: new API", Type: "refactor", Breaking: true},
+		{Hash: "c3", Message: "Update README"},
+	}
+	types := &TypeMapping{KeyChange: DefaultKeyChange}
+
+	plain := typedPitches(t, commits, nil)
+	got := typedPitches(t, commits, types)
+	// Up a fifth is played as down a fourth, to stay in the register.
+	want := []byte{plain[0], plain[1] - 5, plain[2] - 5}
+	if string(got) != string(want) {
+		t.Errorf("notes: got %v, want %v", got, want)
+	}
+
+	gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 480, Scale: ScaleMajor, Types: types})
+	writer, err := gen.Generate(commits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := metaTexts(writer.Tracks()[0], midi.MetaKeySignature)
+	if len(keys) != 2 || keys[0] != "\x00\x00" || keys[1] != "\x01\x00" {
+		t.Errorf("key signatures: got %q, want C major then G major", keys)
+	}
+
+	if gen.modulations(commits[:1]) != nil {
+		t.Error("modulations without a breaking change: want nil")
+	}
+}
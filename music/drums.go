@@ -0,0 +1,84 @@
+package music
+
+import (
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// drumChannel is the General MIDI percussion channel, reserved for the drum
+// track.
+const drumChannel = 9
+
+// General MIDI percussion keys.
+const (
+	bassDrum      = 36
+	acousticSnare = 38
+	closedHiHat   = 42
+	crashCymbal   = 49
+)
+
+const (
+	// largeChange is the number of changed lines from which a commit is
+	// played on the bass drum rather than the hi-hat.
+	largeChange = 200
+	// snareRoll is the number of strokes in the roll played for a revert.
+	snareRoll = 4
+)
+
+// generateDrums generates the percussion part on the drum channel.
+func (g *Generator) generateDrums(commits []git.Commit, schedule []scheduledNote) *midi.Timeline {
+	timeline := drumTrack()
+	for i, commit := range commits {
+		g.addDrums(timeline, commit, i, schedule[i])
+	}
+	return timeline
+}
+
+// drumTrack returns an empty percussion part.
+func drumTrack() *midi.Timeline {
+	timeline := midi.NewTimeline()
+	timeline.Add(0, midi.TrackName("Drums"))
+	timeline.AddProgramChange(0, drumChannel, 0)
+	addChannelSetup(timeline, drumChannel, channelVolume, panCenter)
+	return timeline
+}
+
+// addDrums plays a commit on the drum channel: merges hit the crash cymbal,
+// reverts play a snare roll, large changes the bass drum and everything else
+// the hi-hat.
+func (g *Generator) addDrums(timeline *midi.Timeline, commit git.Commit, index int, slot scheduledNote) {
+	velocity := g.mapper.Velocity(commit, index)
+	hit := g.drumHit(slot)
+
+	switch {
+	case commit.IsMerge():
+		timeline.AddNote(slot.tick, hit, drumChannel, crashCymbal, velocity)
+	case commit.IsRevert():
+		// A crescendo of evenly spaced strokes across the note.
+		stroke := slot.duration / snareRoll
+		if stroke == 0 {
+			stroke = 1
+		}
+		for j := uint32(0); j < snareRoll; j++ {
+			accent := int(velocity) * int(j+snareRoll) / (2*snareRoll - 1)
+			timeline.AddNote(slot.tick+j*stroke, stroke, drumChannel, acousticSnare, byte(accent))
+		}
+	case commit.ChangeSize() >= largeChange:
+		timeline.AddNote(slot.tick, hit, drumChannel, bassDrum, velocity)
+	default:
+		timeline.AddNote(slot.tick, hit, drumChannel, closedHiHat, velocity)
+	}
+}
+
+// drumHit returns the length of a drum note: a sixteenth note, or the slot
+// if it is shorter.
+func (g *Generator) drumHit(slot scheduledNote) uint32 {
+	hit := uint32(g.config.Ticks) / 4
+	if slot.duration > 0 && slot.duration < hit {
+		return slot.duration
+	}
+	if hit == 0 {
+		return 1
+	}
+	return hit
+}
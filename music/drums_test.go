@@ -0,0 +1,59 @@
+package music
+
+import (
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+)
+
+func TestDrums(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Message: "Merge branch 'feature'"},
+		{Hash: "b2", Message: "Revert \"Add feature\""},
+		{Hash: "c3", Message: "Rewrite parser", Insertions: 150, Deletions: 90},
+		{Hash: "d4", Message: "Fix typo", Insertions: 1, Deletions: 1},
+	}
+
+	gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 480, Scale: ScaleMajor, Drums: true})
+	timeline := gen.generateDrums(commits, gen.schedule(commits))
+
+	var keys []byte
+	for _, event := range timeline.Events() {
+		if event.Data[0] == 0x90|drumChannel && event.Data[2] > 0 {
+			keys = append(keys, event.Data[1])
+		}
+	}
+
+	want := []byte{crashCymbal, acousticSnare, acousticSnare, acousticSnare, acousticSnare, bassDrum, closedHiHat}
+	if string(keys) != string(want) {
+		t.Errorf("drum keys: got %v, want %v", keys, want)
+	}
+}
+
+func TestMelodicChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		harmony  Harmony
+		reserved []byte
+	}{
+		{name: "drums", harmony: HarmonyOff, reserved: []byte{drumChannel}},
+		{name: "harmony", harmony: HarmonyWeek, reserved: []byte{drumChannel, padChannel, bassChannel}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&Config{Harmony: tt.harmony})
+			channels := gen.melodicChannels()
+			if len(channels) != 16-len(tt.reserved) {
+				t.Fatalf("got %d channels, want %d", len(channels), 16-len(tt.reserved))
+			}
+			for _, channel := range channels {
+				for _, reserved := range tt.reserved {
+					if channel == reserved {
+						t.Errorf("reserved channel %d assigned to a melodic part", channel)
+					}
+				}
+			}
+		})
+	}
+}
@@ -8,5 +8,13 @@ var (
 
 	// ErrInvalidMode is returned when an invalid generation mode is specified.
 	ErrInvalidMode = errors.New("invalid generation mode")
-)
 
+	// ErrInvalidScale is returned when a scale name or interval list cannot be parsed.
+	ErrInvalidScale = errors.New("invalid scale")
+
+	// ErrInvalidKey is returned when a key name cannot be parsed.
+	ErrInvalidKey = errors.New("invalid key")
+
+	// ErrInvalidRange is returned when a pitch range cannot be parsed.
+	ErrInvalidRange = errors.New("invalid pitch range")
+)
@@ -1,8 +1,7 @@
 package music
 
 import (
-	"hash/fnv"
-	"sort"
+	"io"
 
 	"github.com/klejdi94/git2midi/git"
 	"github.com/klejdi94/git2midi/midi"
@@ -11,14 +10,78 @@ import (
 // Generator generates MIDI music from Git commits.
 type Generator struct {
 	config *Config
+	mapper Mapper
+
+	// chords holds the chord under each commit while generating, or nil
+	// without harmony.
+	chords []chord
+
+	// keys holds the transposition of each commit's key in semitones while
+	// generating, or nil when the key never changes.
+	keys []int
+
+	// voices holds the per-author or lane voices of the last composition.
+	voices []Voice
 }
 
 // Config holds configuration for music generation.
 type Config struct {
+	// Title names the sequence, typically after the repository.
+	Title string
+
 	BPM      int
 	Ticks    int
 	Duration int
 	Mode     Mode
+	Rhythm   Rhythm
+	Time     TimeMapping
+
+	// Scale, Root (pitch class 0-11) and LowNote/HighNote configure the
+	// pitches of the default mapper.
+	Scale    Scale
+	Root     int
+	LowNote  int
+	HighNote int
+
+	// Instrument is the General MIDI program for melodic tracks.
+	// AuthorInstruments overrides it per author in per-author mode.
+	Instrument        byte
+	AuthorInstruments map[string]byte
+
+	// Mapper turns commits into notes. If nil, DefaultMapper is used.
+	Mapper Mapper
+
+	// Lyrics adds commit subjects as lyrics aligned to their notes. Karaoke
+	// writes them as a .kar words track instead of Lyric events.
+	Lyrics  Lyrics
+	Karaoke bool
+
+	// Tagged commits get a Marker event and the TagAccent. MarkBranches
+	// also marks commits at branch heads.
+	TagAccent    Accent
+	MarkBranches bool
+
+	// Drums adds a percussion track on the General MIDI drum channel.
+	Drums bool
+
+	// Allocation assigns authors to voices in per-author mode. Voices caps
+	// the number of dedicated voices of AllocationTop (0 = one per free
+	// channel).
+	Allocation Allocation
+	Voices     int
+
+	// CoAuthorInterval is the number of scale steps between the voices of
+	// a commit's author and co-authors in per-author mode (0 = unison).
+	CoAuthorInterval int
+
+	// Harmony adds pad and bass tracks following a chord progression and
+	// snaps melody notes on strong beats to chord tones.
+	Harmony Harmony
+
+	// Types plays commits by their Conventional Commits type, each in its
+	// register and with its contour, and changes key at breaking changes.
+	// If nil, every commit is played alike.
+	Types *TypeMapping
 }
 
 // Mode represents the generation mode.
@@ -29,12 +92,25 @@ const (
 	ModeSingleTrack Mode = iota
 	// ModePerAuthor generates separate tracks per author.
 	ModePerAuthor
+	// ModeLanes generates separate tracks per branch lane of the commit
+	// graph.
+	ModeLanes
 )
 
 // NewGenerator creates a new music generator with the given configuration.
 func NewGenerator(cfg *Config) *Generator {
+	mapper := cfg.Mapper
+	if mapper == nil {
+		mapper = DefaultMapper{
+			Scale: cfg.Scale,
+			Root:  cfg.Root,
+			Low:   cfg.LowNote,
+			High:  cfg.HighNote,
+		}
+	}
 	return &Generator{
 		config: cfg,
+		mapper: mapper,
 	}
 }
 
@@ -44,151 +120,335 @@ func (g *Generator) Generate(commits []git.Commit) (*midi.Writer, error) {
 		return nil, ErrNoCommits
 	}
 
-	format := uint16(0)
-	if g.config.Mode == ModePerAuthor {
-		format = 1
-	}
-
-	writer := midi.NewWriter(format, uint16(g.config.Ticks))
-	tempo := midi.BPMToMicrosecondsPerQuarter(g.config.BPM)
+	schedule := g.schedule(commits)
+	g.keys = g.modulations(commits)
+	g.chords = g.harmonize(commits)
+	g.voices = nil
 
+	var parts []*midi.Timeline
 	switch g.config.Mode {
 	case ModeSingleTrack:
-		if err := g.generateSingleTrack(writer, commits, tempo); err != nil {
-			return nil, err
-		}
+		parts = g.generateSingleTrack(commits, schedule)
 	case ModePerAuthor:
-		if err := g.generatePerAuthorTracks(writer, commits, tempo); err != nil {
-			return nil, err
-		}
+		parts = g.generatePerAuthorTracks(commits, schedule)
+	case ModeLanes:
+		parts = g.generateLaneTracks(commits, schedule)
 	default:
 		return nil, ErrInvalidMode
 	}
 
-	return writer, nil
-}
-
-// generateSingleTrack generates a single MIDI track from all commits.
-func (g *Generator) generateSingleTrack(writer *midi.Writer, commits []git.Commit, tempo uint32) error {
-	track := midi.NewTrack()
-	track.AddTempo(0, tempo)
+	if g.chords != nil {
+		parts = append(parts, g.generateHarmony(g.chords, schedule)...)
+	}
+	if g.config.Drums {
+		parts = append(parts, g.generateDrums(commits, schedule))
+	}
 
-	currentTime := uint32(0)
-	for i, commit := range commits {
-		pitch := g.hashToPitch(commit.Hash)
-		velocity := g.messageToVelocity(commit.Message)
-		deltaTime := g.calculateRhythm(i, uint32(g.config.Duration))
+	conductor := g.conductor()
+	g.addKeyChanges(conductor, schedule)
+	g.addMarkers(conductor, commits, schedule)
+	if g.lyrics() != LyricsOff {
+		if g.config.Karaoke {
+			parts = append([]*midi.Timeline{g.karaokeWords(commits, schedule)}, parts...)
+		} else {
+			g.addLyrics(conductor, commits, schedule)
+		}
+	}
 
-		track.AddNoteOn(currentTime, 0, pitch, velocity)
-		track.AddNoteOff(deltaTime, 0, pitch, 64)
+	// A single-track composition is written as one format 0 track; otherwise
+	// the conductor leads a format 1 file with every part on its own track.
+	if g.config.Mode == ModeSingleTrack && !g.config.Karaoke {
+		for _, part := range parts {
+			conductor.Merge(part)
+		}
+		writer := midi.NewWriter(0, uint16(g.config.Ticks))
+		writer.AddTrack(conductor.Compile())
+		return writer, nil
+	}
 
-	currentTime = deltaTime * 3 / 4
+	// Keep every track as long as the whole piece.
+	end := scheduleEnd(schedule)
+	writer := midi.NewWriter(1, uint16(g.config.Ticks))
+	conductor.SetEnd(end)
+	writer.AddTrack(conductor.Compile())
+	for _, part := range parts {
+		part.SetEnd(end)
+		writer.AddTrack(part.Compile())
 	}
 
-	track.AddEndOfTrack(0)
-	writer.AddTrack(track)
-	return nil
+	return writer, nil
 }
 
-func (g *Generator) generatePerAuthorTracks(writer *midi.Writer, commits []git.Commit, tempo uint32) error {
-	authorCommits := make(map[string][]git.Commit)
-	for _, commit := range commits {
-		authorCommits[commit.Author] = append(authorCommits[commit.Author], commit)
+// Streams reports whether GenerateFrom plays commits as they are read: in a
+// single track in fixed rhythm, without harmony or karaoke words, no note
+// depends on later commits.
+func (g *Generator) Streams() bool {
+	return g.config.Mode == ModeSingleTrack && g.config.Rhythm == RhythmFixed &&
+		g.config.Harmony == HarmonyOff && !g.config.Karaoke
+}
+
+// GenerateFrom creates MIDI tracks from the commits of an iterator, oldest
+// first, like Generate. When the configuration Streams, every commit is
+// played as soon as it is read and then dropped, so memory grows with the
+// MIDI events written but not with the commits; otherwise the commits are
+// collected for Generate. The caller closes the iterator.
+func (g *Generator) GenerateFrom(commits git.CommitIterator) (*midi.Writer, error) {
+	if !g.Streams() {
+		history, err := git.Collect(commits)
+		if err != nil {
+			return nil, err
+		}
+		return g.Generate(history)
 	}
 
-	authors := make([]string, 0, len(authorCommits))
-	for author := range authorCommits {
-		authors = append(authors, author)
+	g.keys, g.chords, g.voices = nil, nil, nil
+	melody := g.melodyTrack()
+	var drums *midi.Timeline
+	if g.config.Drums {
+		drums = drumTrack()
 	}
-	sort.Strings(authors)
 
-	for channel, author := range authors {
-		if channel > 15 {
-			channel = 15
+	// The conductor starts in the key of the first commit.
+	var conductor *midi.Timeline
+	tick, shift := uint32(0), 0
+	for i := 0; ; i++ {
+		commit, err := commits.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
 		}
 
-		track := midi.NewTrack()
-		track.AddTempo(0, tempo)
-
-		currentTime := uint32(0)
-		for i, commit := range authorCommits[author] {
-			pitch := g.hashToPitch(commit.Hash)
-			velocity := g.messageToVelocity(commit.Message)
-			deltaTime := g.calculateRhythm(i, uint32(g.config.Duration))
+		if g.changesKey() {
+			shift = g.modulate(shift, commit)
+			g.keys = append(g.keys, shift)
+		}
+		slot := scheduledNote{tick: tick, duration: g.duration(commit, i)}
+		tick = slot.end()
 
-			track.AddNoteOn(currentTime, byte(channel), pitch, velocity)
-			track.AddNoteOff(deltaTime, byte(channel), pitch, 64)
+		if conductor == nil {
+			conductor = g.conductor()
+		}
+		g.addKeyChange(conductor, i, slot)
+		g.addMarker(conductor, commit, slot)
+		if g.lyrics() != LyricsOff {
+			g.addLyric(conductor, commit, slot)
+		}
 
-			currentTime = deltaTime * 3 / 4
+		g.addCommit(melody, commit, i, slot, 0)
+		if drums != nil {
+			g.addDrums(drums, commit, i, slot)
 		}
+	}
+	if conductor == nil {
+		return nil, ErrNoCommits
+	}
+
+	conductor.Merge(melody)
+	if drums != nil {
+		conductor.Merge(drums)
+	}
+	writer := midi.NewWriter(0, uint16(g.config.Ticks))
+	writer.AddTrack(conductor.Compile())
+	return writer, nil
+}
 
-		track.AddEndOfTrack(0)
-		writer.AddTrack(track)
+// generateSingleTrack generates one part with all commits.
+func (g *Generator) generateSingleTrack(commits []git.Commit, schedule []scheduledNote) []*midi.Timeline {
+	timeline := g.melodyTrack()
+	for i, slot := range schedule {
+		g.addCommit(timeline, commits[i], i, slot, 0)
 	}
+	return []*midi.Timeline{timeline}
+}
 
-	return nil
+// melodyTrack returns the empty part of a single-track composition, on the
+// first channel.
+func (g *Generator) melodyTrack() *midi.Timeline {
+	timeline := midi.NewTimeline()
+	timeline.Add(0, midi.InstrumentName(midi.ProgramName(g.config.Instrument)))
+	timeline.AddProgramChange(0, 0, g.config.Instrument)
+	addChannelSetup(timeline, 0, channelVolume, panCenter)
+	return timeline
 }
 
-// hashToPitch converts a Git commit hash to a MIDI note using a pentatonic minor scale.
-// Maps to MIDI range C4-C6 (60-84) for a focused, musical range.
-func (g *Generator) hashToPitch(hash string) byte {
-	h := fnv.New32a()
-	h.Write([]byte(hash))
-	hashValue := h.Sum32()
+// generatePerAuthorTracks generates one part per author.
+func (g *Generator) generatePerAuthorTracks(commits []git.Commit, schedule []scheduledNote) []*midi.Timeline {
+	g.voices = g.allocateVoices(commits)
+
+	parts := make([]*midi.Timeline, len(g.voices))
+	authorVoice := make(map[string]int)
+	for i, voice := range g.voices {
+		timeline := midi.NewTimeline()
+		timeline.Add(0, midi.TrackName(voice.Name))
+		timeline.Add(0, midi.InstrumentName(midi.ProgramName(voice.Program)))
+		timeline.AddProgramChange(0, voice.Channel, voice.Program)
+		addChannelSetup(timeline, voice.Channel, channelVolume, voice.Pan)
+		parts[i] = timeline
+
+		for _, author := range voice.Authors {
+			authorVoice[author] = i
+		}
+	}
 
-	pentatonic := []byte{0, 3, 5, 7, 10}
-	scaleDegree := hashValue % uint32(len(pentatonic))
-	octaveOffset := (hashValue / uint32(len(pentatonic))) % 3
+	for i, commit := range commits {
+		voice := authorVoice[commit.Author]
+		note := g.addCommit(parts[voice], commit, i, schedule[i], g.voices[voice].Channel)
+		g.addCoAuthors(parts, authorVoice, commit, i, note)
+	}
 
-	note := 60 + int(pentatonic[scaleDegree]) + int(octaveOffset)*12
+	return parts
+}
 
-	if note > 84 {
-		note = 84
+// conductor returns a timeline with the sequence-wide events: title, time
+// signature, key signature of the configured scale in the key of the first
+// commit, and tempo.
+func (g *Generator) conductor() *midi.Timeline {
+	timeline := midi.NewTimeline()
+	if g.config.Karaoke {
+		timeline.Add(0, midi.Text(karaokeHeader))
 	}
-	if note < 60 {
-		note = 60
+	if g.config.Title != "" {
+		timeline.Add(0, midi.TrackName(g.config.Title))
 	}
+	timeline.Add(0, midi.TimeSignature(4, 4))
+	sharps, minor := g.config.Scale.KeySignature(g.keyRoot(0))
+	timeline.Add(0, midi.KeySignature(sharps, minor))
+	timeline.AddTempo(0, midi.BPMToMicrosecondsPerQuarter(g.config.BPM))
+	return timeline
+}
 
-	return byte(note)
+const (
+	// channelVolume is the channel volume set on every generated track.
+	channelVolume = 100
+	// panCenter is the pan position of a centered track.
+	panCenter = 64
+)
+
+// addChannelSetup sets the volume and stereo position of a channel at the
+// start of a track.
+func addChannelSetup(timeline *midi.Timeline, channel, volume, pan byte) {
+	timeline.AddControlChange(0, channel, midi.CCVolume, volume)
+	timeline.AddControlChange(0, channel, midi.CCPan, pan)
 }
 
-// messageToVelocity converts commit message length to MIDI velocity (40-127).
-func (g *Generator) messageToVelocity(message string) byte {
+// spreadPan spreads count tracks evenly across the stereo field, keeping a
+// margin at each edge, and returns the pan position of track index.
+func spreadPan(index, count int) byte {
 	const (
-		maxMessageLength = 200
-		minVelocity      = 40
-		maxVelocity      = 127
+		left  = 16
+		right = 111
 	)
+	if count <= 1 {
+		return panCenter
+	}
+	return byte(left + index*(right-left)/(count-1))
+}
 
-	length := len(message)
-	if length > maxMessageLength {
-		length = maxMessageLength
+// melodicChannels returns the MIDI channels free for melodic parts: all but
+// the drum channel and, with harmony, the pad and bass channels.
+func (g *Generator) melodicChannels() []byte {
+	channels := make([]byte, 0, 16)
+	for channel := byte(0); channel < 16; channel++ {
+		if channel == drumChannel {
+			continue
+		}
+		if g.config.Harmony != HarmonyOff && (channel == padChannel || channel == bassChannel) {
+			continue
+		}
+		channels = append(channels, channel)
 	}
+	return channels
+}
 
-	velocity := minVelocity + (length*(maxVelocity-minVelocity))/maxMessageLength
-	if velocity > maxVelocity {
-		velocity = maxVelocity
+// authorInstrument returns the General MIDI program for an author's track.
+func (g *Generator) authorInstrument(author string) byte {
+	if program, ok := g.config.AuthorInstruments[author]; ok {
+		return program
 	}
-	if velocity < minVelocity {
-		velocity = minVelocity
+	return g.config.Instrument
+}
+
+// addCommit places the note for a commit on the timeline at its scheduled
+// slot, in the register, contour and key of its type, along with any extra
+// events the mapper provides, and returns it.
+func (g *Generator) addCommit(timeline *midi.Timeline, commit git.Commit, index int, slot scheduledNote, channel byte) Note {
+	note := Note{
+		Tick:     slot.tick,
+		Duration: slot.duration,
+		Channel:  g.mapper.Channel(commit, index, channel),
+		Pitch:    g.mapper.Pitch(commit, index),
+		Velocity: g.mapper.Velocity(commit, index),
+	}
+	style, _ := g.typeStyle(commit)
+	note.Pitch = transpose(note.Pitch, 12*style.Octave+g.keyShift(index))
+	if g.chords != nil && g.onStrongBeat(note.Tick) {
+		note.Pitch = snapToChord(note.Pitch, g.chords[index])
 	}
+	g.playNote(timeline, note, style.Contour, index)
+
+	if commit.IsTagged() {
+		g.addAccent(timeline, note, g.keyRoot(index))
+	}
+
+	if eventMapper, ok := g.mapper.(EventMapper); ok {
+		for _, event := range eventMapper.Events(commit, index, note) {
+			timeline.Add(event.Tick, event.Data)
+		}
+	}
+
+	return note
+}
 
-	return byte(velocity)
+// scheduledNote is the position of a commit's note in the composition.
+type scheduledNote struct {
+	tick     uint32
+	duration uint32
 }
 
-// calculateRhythm calculates note duration with rhythm variations.
-func (g *Generator) calculateRhythm(index int, baseDuration uint32) uint32 {
-	if index == 0 {
-		return baseDuration
+// end returns the tick at which the note is released.
+func (n scheduledNote) end() uint32 {
+	return n.tick + n.duration
+}
+
+// scheduleEnd returns the tick at which the last scheduled note is released.
+func scheduleEnd(notes []scheduledNote) uint32 {
+	end := uint32(0)
+	for _, note := range notes {
+		if note.end() > end {
+			end = note.end()
+		}
 	}
+	return end
+}
 
-	if index%4 == 0 {
-		return baseDuration * 3 / 4
+// schedule places every commit on the shared time axis in commit order.
+// In fixed rhythm each note starts when the previous one ends.
+func (g *Generator) schedule(commits []git.Commit) []scheduledNote {
+	if g.config.Rhythm == RhythmTimestamp {
+		return g.timestampSchedule(commits)
 	}
-	if index%8 == 0 {
-		return baseDuration * 5 / 4
+
+	notes := make([]scheduledNote, len(commits))
+
+	tick := uint32(0)
+	for i, commit := range commits {
+		duration := g.duration(commit, i)
+		notes[i] = scheduledNote{tick: tick, duration: duration}
+		tick += duration
 	}
 
-	return baseDuration
+	return notes
 }
 
+// duration returns the mapped length of a commit's note, at least one tick:
+// a note-off sorts before a note-on at the same tick, so a zero-length note
+// would sound until the end of the track.
+func (g *Generator) duration(commit git.Commit, index int) uint32 {
+	duration := g.mapper.Duration(commit, index, uint32(g.config.Duration))
+	if duration == 0 {
+		return 1
+	}
+	return duration
+}
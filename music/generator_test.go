@@ -0,0 +1,82 @@
+package music
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+)
+
+// sliceIterator yields the commits of a slice.
+type sliceIterator struct {
+	commits []git.Commit
+}
+
+func (s *sliceIterator) Next() (git.Commit, error) {
+	if len(s.commits) == 0 {
+		return git.Commit{}, io.EOF
+	}
+	commit := s.commits[0]
+	s.commits = s.commits[1:]
+	return commit, nil
+}
+
+func (s *sliceIterator) Close() error {
+	return nil
+}
+
+func TestGenerateFrom(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Message: "Initial commit", Timestamp: 1700000000, Insertions: 40, Tags: []string{"v0.1.0"}},
+		{Hash: "b2", Message: "feat: add parser", Type: "feat", Timestamp: 1700003600, Insertions: 200, Branches: []string{"main"}},
+		{Hash: "c3", Message: "refactor!: new API", Type: "refactor", Breaking: true, Timestamp: 1700007200, Insertions: 900, Deletions: 300},
+		{Hash: "d4", Message: "Merge branch 'fix'", Parents: []string{"c3", "f6"}, Timestamp: 1700010800},
+		{Hash: "e5", Message: "Revert \"feat: add parser\"", Timestamp: 1700014400, Deletions: 200},
+	}
+	base := Config{BPM: 120, Ticks: 480, Duration: 240, Scale: ScaleMajor}
+
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		streams bool
+	}{
+		{name: "plain", change: func(*Config) {}, streams: true},
+		{name: "drums", change: func(c *Config) { c.Drums = true }, streams: true},
+		{name: "lyrics", change: func(c *Config) { c.Lyrics = LyricsWords }, streams: true},
+		{name: "markers", change: func(c *Config) { c.MarkBranches = true; c.TagAccent = AccentCadence }, streams: true},
+		{name: "key changes", change: func(c *Config) { c.Types = DefaultTypeMapping() }, streams: true},
+		{name: "harmony", change: func(c *Config) { c.Harmony = HarmonyWeek }},
+		{name: "karaoke", change: func(c *Config) { c.Lyrics = LyricsSubject; c.Karaoke = true }},
+		{name: "timestamps", change: func(c *Config) { c.Rhythm = RhythmTimestamp }},
+		{name: "per author", change: func(c *Config) { c.Mode = ModePerAuthor }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := base
+			tt.change(&config)
+
+			gen := NewGenerator(&config)
+			if gen.Streams() != tt.streams {
+				t.Errorf("streams: got %v, want %v", gen.Streams(), tt.streams)
+			}
+			want, err := gen.Generate(commits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewGenerator(&config).GenerateFrom(&sliceIterator{commits: commits})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Error("GenerateFrom and Generate wrote different files")
+			}
+		})
+	}
+
+	if _, err := NewGenerator(&base).GenerateFrom(&sliceIterator{}); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("no commits: got %v, want %v", err, ErrNoCommits)
+	}
+}
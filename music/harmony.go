@@ -0,0 +1,235 @@
+package music
+
+import (
+	"time"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// Harmony selects how the history is divided into chords.
+type Harmony int
+
+const (
+	// HarmonyOff plays the melody alone.
+	HarmonyOff Harmony = iota
+	// HarmonyWeek changes chord with each calendar week of commits.
+	HarmonyWeek
+	// HarmonyMonth changes chord with each calendar month of commits.
+	HarmonyMonth
+	// HarmonyCluster changes chord after every pause in activity longer
+	// than clusterGap.
+	HarmonyCluster
+)
+
+const (
+	// clusterGap is the pause between commits that starts a new cluster.
+	clusterGap = 8 * time.Hour
+
+	// padChannel and bassChannel carry the harmony parts.
+	padChannel  = 14
+	bassChannel = 15
+
+	// padProgram and bassProgram are the General MIDI programs of the
+	// harmony parts: Pad 2 (warm) and Electric Bass (finger).
+	padProgram  = 89
+	bassProgram = 33
+
+	padVelocity  = 56
+	bassVelocity = 84
+)
+
+// Progressions as scale degrees (0 is the tonic), cycled segment by segment:
+// I-vi-IV-V in major keys and i-VI-III-VII in minor keys.
+var (
+	majorProgression = []int{0, 5, 3, 4}
+	minorProgression = []int{0, 5, 2, 6}
+)
+
+// chord is a triad as pitch classes, root first.
+type chord [3]int
+
+// transpose returns the chord moved by semitones.
+func (c chord) transpose(semitones int) chord {
+	for i := range c {
+		c[i] = ((c[i]+semitones)%12 + 12) % 12
+	}
+	return c
+}
+
+// contains reports whether pitch belongs to the chord.
+func (c chord) contains(pitch int) bool {
+	for _, pc := range c {
+		if pitch%12 == pc {
+			return true
+		}
+	}
+	return false
+}
+
+// harmonize assigns a chord to every commit: the history is split into
+// segments by the harmony setting and each segment takes the next chord of
+// the progression, with the last segment resolving to the tonic. Chords
+// follow the key changes of breaking changes. It returns nil when harmony
+// is off.
+func (g *Generator) harmonize(commits []git.Commit) []chord {
+	if g.config.Harmony == HarmonyOff {
+		return nil
+	}
+
+	progression := majorProgression
+	if _, minor := g.config.Scale.KeySignature(g.config.Root); minor {
+		progression = minorProgression
+	}
+
+	starts := g.segments(commits)
+	chords := make([]chord, len(commits))
+	for k, start := range starts {
+		end := len(commits)
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+
+		degree := progression[k%len(progression)]
+		if k == len(starts)-1 {
+			degree = 0
+		}
+		for i := start; i < end; i++ {
+			chords[i] = g.triad(degree).transpose(g.keyShift(i))
+		}
+	}
+	return chords
+}
+
+// segments returns the index of the first commit of every harmony segment.
+func (g *Generator) segments(commits []git.Commit) []int {
+	starts := []int{0}
+	for i := 1; i < len(commits); i++ {
+		prev := time.Unix(commits[i-1].Timestamp, 0).UTC()
+		cur := time.Unix(commits[i].Timestamp, 0).UTC()
+
+		var boundary bool
+		switch g.config.Harmony {
+		case HarmonyWeek:
+			prevYear, prevWeek := prev.ISOWeek()
+			year, week := cur.ISOWeek()
+			boundary = year != prevYear || week != prevWeek
+		case HarmonyMonth:
+			boundary = cur.Year() != prev.Year() || cur.Month() != prev.Month()
+		case HarmonyCluster:
+			boundary = cur.Sub(prev) > clusterGap
+		}
+
+		if boundary {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+// triad returns the diatonic triad on a scale degree. Scales without seven
+// notes borrow the chords of the major or natural minor scale on the root.
+func (g *Generator) triad(degree int) chord {
+	scale := g.config.Scale.Intervals
+	if len(scale) != 7 {
+		scale = ScaleMajor.Intervals
+		if _, minor := g.config.Scale.KeySignature(g.config.Root); minor {
+			scale = ScaleNaturalMinor.Intervals
+		}
+	}
+
+	var c chord
+	for i := range c {
+		c[i] = (g.config.Root + scale[(degree+2*i)%7]) % 12
+	}
+	return c
+}
+
+// generateHarmony generates the pad part, sustaining each segment's chord an
+// octave below the melody, and the bass part, repeating its root every half
+// note two octaves below.
+func (g *Generator) generateHarmony(chords []chord, schedule []scheduledNote) []*midi.Timeline {
+	pad := midi.NewTimeline()
+	pad.Add(0, midi.TrackName("Pad"))
+	pad.Add(0, midi.InstrumentName(midi.ProgramName(padProgram)))
+	pad.AddProgramChange(0, padChannel, padProgram)
+	addChannelSetup(pad, padChannel, channelVolume, panCenter)
+
+	bass := midi.NewTimeline()
+	bass.Add(0, midi.TrackName("Bass"))
+	bass.Add(0, midi.InstrumentName(midi.ProgramName(bassProgram)))
+	bass.AddProgramChange(0, bassChannel, bassProgram)
+	addChannelSetup(bass, bassChannel, channelVolume, panCenter)
+
+	low := g.lowNote()
+	end := scheduleEnd(schedule)
+	halfNote := 2 * uint32(g.config.Ticks)
+	if halfNote == 0 {
+		halfNote = end + 1
+	}
+
+	for i := 0; i < len(chords); {
+		// Find the run of commits sharing this chord.
+		j := i + 1
+		for j < len(chords) && chords[j] == chords[i] {
+			j++
+		}
+
+		start, stop := schedule[i].tick, end
+		if j < len(chords) {
+			stop = schedule[j].tick
+		}
+
+		if stop > start {
+			root := pitchAbove(chords[i][0], low-12)
+			for _, pc := range chords[i] {
+				pad.AddNote(start, stop-start, padChannel, chordTone(pitchAbove(pc, root)), padVelocity)
+			}
+
+			bassNote := chordTone(pitchAbove(chords[i][0], low-24))
+			for tick := start; tick < stop; tick += halfNote {
+				length := halfNote
+				if stop-tick < length {
+					length = stop - tick
+				}
+				bass.AddNote(tick, length, bassChannel, bassNote, bassVelocity)
+			}
+		}
+
+		i = j
+	}
+
+	return []*midi.Timeline{pad, bass}
+}
+
+// onStrongBeat reports whether tick falls on beat one or three of a 4/4 bar.
+func (g *Generator) onStrongBeat(tick uint32) bool {
+	halfNote := 2 * uint32(g.config.Ticks)
+	return halfNote > 0 && tick%halfNote == 0
+}
+
+// snapToChord returns the chord tone nearest to pitch, preferring the lower
+// one on a tie.
+func snapToChord(pitch byte, c chord) byte {
+	for d := 0; d <= 6; d++ {
+		for _, p := range []int{int(pitch) - d, int(pitch) + d} {
+			if p >= 0 && p <= 127 && c.contains(p) {
+				return byte(p)
+			}
+		}
+	}
+	return pitch
+}
+
+// lowNote returns the bottom of the configured pitch range.
+func (g *Generator) lowNote() int {
+	if g.config.LowNote == 0 && g.config.HighNote == 0 {
+		return DefaultLowNote
+	}
+	return g.config.LowNote
+}
+
+// pitchAbove returns the lowest pitch of pitch class pc at or above floor.
+func pitchAbove(pc, floor int) int {
+	return floor + ((pc-floor)%12+12)%12
+}
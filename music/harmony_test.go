@@ -0,0 +1,88 @@
+package music
+
+import (
+	"testing"
+	"time"
+
+	"github.com/klejdi94/git2midi/git"
+)
+
+func TestTriad(t *testing.T) {
+	tests := []struct {
+		name   string
+		scale  Scale
+		root   int
+		degree int
+		want   chord
+	}{
+		{name: "C major tonic", scale: ScaleMajor, root: 0, degree: 0, want: chord{0, 4, 7}},
+		{name: "C major submediant", scale: ScaleMajor, root: 0, degree: 5, want: chord{9, 0, 4}},
+		{name: "G major dominant", scale: ScaleMajor, root: 7, degree: 4, want: chord{2, 6, 9}},
+		{name: "A pentatonic minor borrows minor", scale: ScalePentatonicMinor, root: 9, degree: 0, want: chord{9, 0, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&Config{Scale: tt.scale, Root: tt.root})
+			if got := gen.triad(tt.degree); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHarmonize(t *testing.T) {
+	day := int64(24 * time.Hour / time.Second)
+	jan := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC).Unix()
+	commits := []git.Commit{
+		{Hash: "a1", Timestamp: jan},
+		{Hash: "b2", Timestamp: jan + day},
+		{Hash: "c3", Timestamp: jan + 31*day},
+		{Hash: "d4", Timestamp: jan + 62*day},
+		{Hash: "e5", Timestamp: jan + 62*day + 3600},
+	}
+
+	tests := []struct {
+		name    string
+		harmony Harmony
+		want    []chord
+	}{
+		{name: "off", harmony: HarmonyOff},
+		{name: "month", harmony: HarmonyMonth, want: []chord{{0, 4, 7}, {0, 4, 7}, {9, 0, 4}, {0, 4, 7}, {0, 4, 7}}},
+		{name: "cluster", harmony: HarmonyCluster, want: []chord{{0, 4, 7}, {9, 0, 4}, {5, 9, 0}, {0, 4, 7}, {0, 4, 7}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&Config{Scale: ScaleMajor, Harmony: tt.harmony})
+			got := gen.harmonize(commits)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("commit %d: got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSnapToChord(t *testing.T) {
+	c := chord{0, 4, 7}
+	tests := []struct {
+		pitch byte
+		want  byte
+	}{
+		{pitch: 60, want: 60},
+		{pitch: 62, want: 60},
+		{pitch: 66, want: 67},
+		{pitch: 70, want: 72},
+	}
+
+	for _, tt := range tests {
+		if got := snapToChord(tt.pitch, c); got != tt.want {
+			t.Errorf("snapToChord(%d): got %d, want %d", tt.pitch, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package music
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// laneInterval is the number of scale steps between the voices of a merge
+// chord or a fork split (a third).
+const laneInterval = 2
+
+// generateLaneTracks generates one part per branch lane of the commit graph,
+// as drawn by git log --graph. A merge is played as a chord joining its own
+// voice and the voices of its parents' lanes; a fork splits its note onto
+// the voices of the lanes branching from it, alternately above and below.
+func (g *Generator) generateLaneTracks(commits []git.Commit, schedule []scheduledNote) []*midi.Timeline {
+	graph := git.NewGraph(commits)
+	g.voices = g.laneVoices(graph.Lanes)
+
+	parts := make([]*midi.Timeline, len(g.voices))
+	for i, voice := range g.voices {
+		timeline := midi.NewTimeline()
+		timeline.Add(0, midi.TrackName(voice.Name))
+		timeline.Add(0, midi.InstrumentName(midi.ProgramName(voice.Program)))
+		timeline.AddProgramChange(0, voice.Channel, voice.Program)
+		addChannelSetup(timeline, voice.Channel, channelVolume, voice.Pan)
+		parts[i] = timeline
+	}
+
+	laneVoice := func(lane int) int {
+		return lane % len(parts)
+	}
+
+	for i, commit := range commits {
+		node := graph.Nodes[i]
+		voice := laneVoice(node.Lane)
+		note := g.addCommit(parts[voice], commit, i, schedule[i], g.voices[voice].Channel)
+
+		if node.IsMerge() {
+			joined := map[int]bool{voice: true}
+			steps := 0
+			for _, parent := range node.Parents {
+				other := laneVoice(graph.Nodes[parent].Lane)
+				if joined[other] {
+					continue
+				}
+				joined[other] = true
+				steps += laneInterval
+				g.addLaneNote(parts[other], g.voices[other], commit, i, note, steps)
+			}
+		}
+
+		if node.IsFork() {
+			split := map[int]bool{voice: true}
+			for _, child := range node.Children {
+				other := laneVoice(graph.Nodes[child].Lane)
+				if split[other] {
+					continue
+				}
+				split[other] = true
+				steps := len(split) / 2 * laneInterval
+				if len(split)%2 == 1 {
+					steps = -steps
+				}
+				g.addLaneNote(parts[other], g.voices[other], commit, i, note, steps)
+			}
+		}
+	}
+
+	return parts
+}
+
+// addLaneNote plays a commit's note on another lane's voice, steps scale
+// degrees away.
+func (g *Generator) addLaneNote(timeline *midi.Timeline, voice Voice, commit git.Commit, index int, note Note, steps int) {
+	channel := g.mapper.Channel(commit, index, voice.Channel)
+	timeline.AddNote(note.Tick, note.Duration, channel, g.scaleStep(note.Pitch, steps), note.Velocity)
+}
+
+// laneVoices returns a voice per lane, up to the melodic channels
+// available; further lanes share the voices from the first one on. The
+// first lane, the main line, plays the configured instrument.
+func (g *Generator) laneVoices(lanes int) []Voice {
+	channels := g.melodicChannels()
+	count := lanes
+	if count > len(channels) {
+		count = len(channels)
+	}
+
+	voices := make([]Voice, count)
+	for lane := 0; lane < lanes; lane++ {
+		voices[lane%count].Lanes = append(voices[lane%count].Lanes, lane)
+	}
+
+	for i := range voices {
+		voice := &voices[i]
+		voice.Name = laneName(voice.Lanes)
+		voice.Channel = channels[i]
+		voice.Program = hashPrograms[i%len(hashPrograms)]
+		if i == 0 {
+			voice.Program = g.config.Instrument
+		}
+		voice.Pan = spreadPan(i, count)
+	}
+	return voices
+}
+
+// laneName names the voice of lanes, numbering lanes from 1.
+func laneName(lanes []int) string {
+	numbers := make([]string, len(lanes))
+	for i, lane := range lanes {
+		numbers[i] = strconv.Itoa(lane + 1)
+	}
+	if len(lanes) == 1 {
+		return fmt.Sprintf("Lane %s", numbers[0])
+	}
+	return "Lanes " + strings.Join(numbers, ", ")
+}
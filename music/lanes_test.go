@@ -0,0 +1,77 @@
+package music
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+)
+
+func TestLanes(t *testing.T) {
+	// a - b - c ----- e
+	//      \         /
+	//       d ------
+	commits := []git.Commit{
+		{Hash: "a1", Message: "Initial commit"},
+		{Hash: "b2", Parents: []string{"a1"}, Message: "Add parser"},
+		{Hash: "c3", Parents: []string{"b2"}, Message: "Fix parser"},
+		{Hash: "d4", Parents: []string{"b2"}, Message: "Add feature"},
+		{Hash: "e5", Parents: []string{"c3", "d4"}, Message: "Merge branch 'feature'"},
+	}
+
+	gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 120, Mode: ModeLanes, Scale: ScaleMajor})
+	writer, err := gen.Generate(commits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	voices := gen.Voices()
+	if len(voices) != 2 || voices[0].Name != "Lane 1" || voices[1].Name != "Lane 2" {
+		t.Fatalf("voices: got %+v, want Lane 1 and Lane 2", voices)
+	}
+	if writer.TrackCount() != 3 {
+		t.Errorf("tracks: got %d, want 2 lanes plus the conductor", writer.TrackCount())
+	}
+
+	mainLine := noteOns(writer.Tracks()[1])
+	branch := noteOns(writer.Tracks()[2])
+
+	// The main line plays a, b, c and e; the branch plays the split at b,
+	// its own commit d and the merge chord at e.
+	if len(mainLine) != 4 || len(branch) != 3 {
+		t.Fatalf("notes per lane: got %d, %d, want 4, 3", len(mainLine), len(branch))
+	}
+	if want := gen.scaleStep(mainLine[1], laneInterval); branch[0] != want {
+		t.Errorf("fork split: got %d, want %d", branch[0], want)
+	}
+	if want := gen.scaleStep(mainLine[3], laneInterval); branch[2] != want {
+		t.Errorf("merge chord: got %d, want %d", branch[2], want)
+	}
+}
+
+func TestLaneVoices(t *testing.T) {
+	gen := NewGenerator(&Config{Scale: ScaleMajor, Instrument: 40, Harmony: HarmonyWeek})
+
+	voices := gen.laneVoices(20)
+	channels := gen.melodicChannels()
+	if len(voices) != len(channels) {
+		t.Fatalf("voices: got %d, want one per melodic channel (%d)", len(voices), len(channels))
+	}
+	if voices[0].Program != 40 {
+		t.Errorf("main line program: got %d, want the configured instrument", voices[0].Program)
+	}
+
+	lanes := 0
+	for i, voice := range voices {
+		if voice.Channel != channels[i] {
+			t.Errorf("voice %d: got channel %d, want %d", i, voice.Channel, channels[i])
+		}
+		lanes += len(voice.Lanes)
+	}
+	if lanes != 20 {
+		t.Errorf("lanes: got %d, want 20", lanes)
+	}
+	if want := fmt.Sprintf("Lanes 1, %d", len(channels)+1); voices[0].Name != want {
+		t.Errorf("shared voice: got %q, want %q", voices[0].Name, want)
+	}
+}
@@ -0,0 +1,113 @@
+package music
+
+import (
+	"strings"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// Lyrics selects how commit subjects are added as lyrics.
+type Lyrics int
+
+const (
+	// LyricsOff adds no lyrics.
+	LyricsOff Lyrics = iota
+	// LyricsSubject adds each commit's subject as one lyric at its note.
+	LyricsSubject
+	// LyricsWords spreads the words of each subject across its note.
+	LyricsWords
+)
+
+const (
+	// karaokeHeader is the text event that identifies a .kar file.
+	karaokeHeader = "@KMIDI KARAOKE FILE"
+	// karaokeLines is the number of lines shown before a karaoke player
+	// clears the screen.
+	karaokeLines = 4
+)
+
+// lyrics returns the lyrics mode in effect. Karaoke files always carry words.
+func (g *Generator) lyrics() Lyrics {
+	if g.config.Karaoke && g.config.Lyrics == LyricsOff {
+		return LyricsWords
+	}
+	return g.config.Lyrics
+}
+
+// lyricWords splits a commit subject into the syllables sung on its note.
+func (g *Generator) lyricWords(message string) []string {
+	if g.lyrics() == LyricsSubject {
+		if subject := strings.Join(strings.Fields(message), " "); subject != "" {
+			return []string{subject}
+		}
+		return nil
+	}
+	return strings.Fields(message)
+}
+
+// addLyrics adds Lyric events for every commit, spreading its words evenly
+// over its note. Each subject ends with a carriage return, which lyric
+// displays treat as the end of a line.
+func (g *Generator) addLyrics(timeline *midi.Timeline, commits []git.Commit, schedule []scheduledNote) {
+	for i, commit := range commits {
+		g.addLyric(timeline, commit, schedule[i])
+	}
+}
+
+// addLyric adds the Lyric events of one commit.
+func (g *Generator) addLyric(timeline *midi.Timeline, commit git.Commit, slot scheduledNote) {
+	words := g.lyricWords(commit.Message)
+	for j, word := range words {
+		if j < len(words)-1 {
+			word += " "
+		} else {
+			word += "\r"
+		}
+		timeline.Add(lyricTick(slot, j, len(words)), midi.Lyric(word))
+	}
+}
+
+// karaokeWords returns the words track of a .kar file. Karaoke players read
+// Text events in which a leading "\" starts a new screen and "/" a new line,
+// so those characters are removed from the subjects themselves.
+func (g *Generator) karaokeWords(commits []git.Commit, schedule []scheduledNote) *midi.Timeline {
+	timeline := midi.NewTimeline()
+	timeline.Add(0, midi.TrackName("Words"))
+	timeline.Add(0, midi.Text("@LENGL"))
+	if g.config.Title != "" {
+		timeline.Add(0, midi.Text("@T"+g.config.Title))
+	}
+
+	sanitize := strings.NewReplacer("\\", "", "/", " ")
+
+	line := 0
+	for i, commit := range commits {
+		words := g.lyricWords(sanitize.Replace(commit.Message))
+		if len(words) == 0 {
+			continue
+		}
+
+		for j, word := range words {
+			if j == 0 {
+				if line%karaokeLines == 0 {
+					word = "\\" + word
+				} else {
+					word = "/" + word
+				}
+			}
+			if j < len(words)-1 {
+				word += " "
+			}
+			timeline.Add(lyricTick(schedule[i], j, len(words)), midi.Text(word))
+		}
+		line++
+	}
+
+	return timeline
+}
+
+// lyricTick returns the tick of word index out of count sung on slot.
+func lyricTick(slot scheduledNote, index, count int) uint32 {
+	return slot.tick + uint32(index)*slot.duration/uint32(count)
+}
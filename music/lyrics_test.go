@@ -0,0 +1,85 @@
+package music
+
+import (
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// metaTexts returns the text of every meta event of the given type in a track.
+func metaTexts(track *midi.Track, metaType byte) []string {
+	var texts []string
+	for _, event := range track.Events() {
+		if t, data, ok := midi.ParseMeta(event.Data); ok && t == metaType {
+			texts = append(texts, string(data))
+		}
+	}
+	return texts
+}
+
+func testCommits() []git.Commit {
+	return []git.Commit{
+		{Hash: "a1", Author: "Alice", Message: "Initial commit"},
+		{Hash: "b2", Author: "Bob", Message: "Fix a/b parsing"},
+	}
+}
+
+func TestLyrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		lyrics Lyrics
+		want   []string
+	}{
+		{name: "subject", lyrics: LyricsSubject, want: []string{"Initial commit\r", "Fix a/b parsing\r"}},
+		{name: "words", lyrics: LyricsWords, want: []string{"Initial ", "commit\r", "Fix ", "a/b ", "parsing\r"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 480, Scale: ScaleMajor, Lyrics: tt.lyrics})
+			writer, err := gen.Generate(testCommits())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := metaTexts(writer.Tracks()[0], midi.MetaLyric)
+			if len(got) != len(tt.want) {
+				t.Fatalf("lyrics: got %q, want %q", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("lyric %d: got %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKaraoke(t *testing.T) {
+	gen := NewGenerator(&Config{Title: "repo", BPM: 120, Ticks: 480, Duration: 480, Scale: ScaleMajor, Karaoke: true})
+	writer, err := gen.Generate(testCommits())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if writer.GetFormat() != 1 || writer.TrackCount() != 3 {
+		t.Fatalf("got format %d with %d tracks, want format 1 with 3 tracks", writer.GetFormat(), writer.TrackCount())
+	}
+
+	header := metaTexts(writer.Tracks()[0], midi.MetaText)
+	if len(header) == 0 || header[0] != karaokeHeader {
+		t.Errorf("header: got %q, want %q first", header, karaokeHeader)
+	}
+
+	want := []string{"@LENGL", "@Trepo", "\\Initial ", "commit", "/Fix ", "a ", "b ", "parsing"}
+	got := metaTexts(writer.Tracks()[1], midi.MetaText)
+	if len(got) != len(want) {
+		t.Fatalf("words: got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("word %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package music
+
+import (
+	"hash/fnv"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// Mapper turns commits into note properties. index is the commit's position
+// in the history being generated, oldest first.
+type Mapper interface {
+	// Pitch returns the MIDI note number for the commit.
+	Pitch(commit git.Commit, index int) byte
+	// Velocity returns the MIDI velocity for the commit.
+	Velocity(commit git.Commit, index int) byte
+	// Duration returns the note length in ticks, given the configured base duration.
+	Duration(commit git.Commit, index int, base uint32) uint32
+	// Channel returns the MIDI channel, given the channel of the track being generated.
+	Channel(commit git.Commit, index int, base byte) byte
+}
+
+// EventMapper is an optional interface a Mapper can implement to add extra
+// events for a commit, such as controller changes or additional notes.
+type EventMapper interface {
+	// Events returns events at absolute ticks to add alongside the commit's note.
+	Events(commit git.Commit, index int, note Note) []midi.TimedEvent
+}
+
+// Note describes the note generated for a commit.
+type Note struct {
+	Tick     uint32
+	Duration uint32
+	Channel  byte
+	Pitch    byte
+	Velocity byte
+}
+
+// DefaultMapper is the built-in commit-to-note mapping: pitch from the commit
+// hash, velocity from the message length and a fixed rhythm pattern.
+// The zero value plays C pentatonic minor between C4 and C6.
+type DefaultMapper struct {
+	Scale Scale
+	Root  int
+	Low   int
+	High  int
+}
+
+// Pitch maps the commit hash onto the configured scale and range. The
+// default scale, key and range keep the original mapping, so compositions
+// made before they were configurable sound the same.
+func (m DefaultMapper) Pitch(commit git.Commit, index int) byte {
+	if m.isDefault() {
+		return defaultHashToPitch(commit.Hash)
+	}
+	return hashToPitch(commit.Hash, m.notes())
+}
+
+// isDefault reports whether the mapper plays C pentatonic minor between C4
+// and C6.
+func (m DefaultMapper) isDefault() bool {
+	if m.Root != DefaultRoot {
+		return false
+	}
+	if !(m.Low == 0 && m.High == 0) && !(m.Low == DefaultLowNote && m.High == DefaultHighNote) {
+		return false
+	}
+	if len(m.Scale.Intervals) == 0 {
+		return true
+	}
+	if len(m.Scale.Intervals) != len(ScalePentatonicMinor.Intervals) {
+		return false
+	}
+	for i, interval := range m.Scale.Intervals {
+		if interval != ScalePentatonicMinor.Intervals[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// notes returns the notes pitches are drawn from, applying defaults for
+// unset fields.
+func (m DefaultMapper) notes() []byte {
+	low, high := m.Low, m.High
+	if low == 0 && high == 0 {
+		low, high = DefaultLowNote, DefaultHighNote
+	}
+	return m.Scale.Notes(m.Root, low, high)
+}
+
+// Velocity maps the commit message length to a velocity.
+func (DefaultMapper) Velocity(commit git.Commit, index int) byte {
+	return messageToVelocity(commit.Message)
+}
+
+// Duration applies the rhythm pattern to the base duration.
+func (DefaultMapper) Duration(commit git.Commit, index int, base uint32) uint32 {
+	return calculateRhythm(index, base)
+}
+
+// Channel keeps the track's channel.
+func (DefaultMapper) Channel(commit git.Commit, index int, base byte) byte {
+	return base
+}
+
+// hashToPitch converts a Git commit hash to one of the given scale notes.
+func hashToPitch(hash string, notes []byte) byte {
+	if len(notes) == 0 {
+		return DefaultLowNote
+	}
+	return notes[hashValue(hash)%uint32(len(notes))]
+}
+
+// defaultHashToPitch converts a Git commit hash to a degree of C pentatonic
+// minor in one of three octaves from C4, clamped to C6: the mapping used
+// before scales and ranges were configurable.
+func defaultHashToPitch(hash string) byte {
+	value := hashValue(hash)
+	intervals := ScalePentatonicMinor.Intervals
+	degree := value % uint32(len(intervals))
+	octave := (value / uint32(len(intervals))) % 3
+
+	note := DefaultLowNote + intervals[degree] + int(octave)*12
+	if note > DefaultHighNote {
+		note = DefaultHighNote
+	}
+	return byte(note)
+}
+
+// hashValue returns the FNV-1a hash of a commit hash.
+func hashValue(hash string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(hash))
+	return h.Sum32()
+}
+
+// messageToVelocity converts commit message length to MIDI velocity (40-127).
+func messageToVelocity(message string) byte {
+	const (
+		maxMessageLength = 200
+		minVelocity      = 40
+		maxVelocity      = 127
+	)
+
+	length := len(message)
+	if length > maxMessageLength {
+		length = maxMessageLength
+	}
+
+	velocity := minVelocity + (length*(maxVelocity-minVelocity))/maxMessageLength
+	if velocity > maxVelocity {
+		velocity = maxVelocity
+	}
+	if velocity < minVelocity {
+		velocity = minVelocity
+	}
+
+	return byte(velocity)
+}
+
+// calculateRhythm calculates note duration with rhythm variations.
+func calculateRhythm(index int, baseDuration uint32) uint32 {
+	if index == 0 {
+		return baseDuration
+	}
+
+	if index%4 == 0 {
+		return baseDuration * 3 / 4
+	}
+	if index%8 == 0 {
+		return baseDuration * 5 / 4
+	}
+
+	return baseDuration
+}
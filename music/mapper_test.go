@@ -0,0 +1,224 @@
+package music
+
+import (
+	"fmt"
+	"hash/fnv"
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// checkNotesReleased fails the test if a note-off in the track comes before
+// its note-on or a note is never released.
+func checkNotesReleased(t *testing.T, track *midi.Track) {
+	t.Helper()
+	sounding := make(map[byte]int)
+	for _, event := range track.Timeline().Events() {
+		status, data := event.Data[0]&0xF0, event.Data
+		switch {
+		case status == 0x90 && data[2] > 0:
+			sounding[data[1]]++
+		case status == 0x80 || status == 0x90:
+			sounding[data[1]]--
+			if sounding[data[1]] < 0 {
+				t.Fatalf("note %d released before it starts at tick %d", data[1], event.Tick)
+			}
+		}
+	}
+	for note, count := range sounding {
+		if count != 0 {
+			t.Errorf("note %d never released", note)
+		}
+	}
+}
+
+func TestMinimumDuration(t *testing.T) {
+	var commits []git.Commit
+	for i := 0; i < 9; i++ {
+		commits = append(commits, git.Commit{Hash: fmt.Sprintf("%07d", i), Message: "change"})
+	}
+
+	for _, rhythm := range []Rhythm{RhythmFixed, RhythmTimestamp} {
+		gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 1, Scale: ScaleMajor, Rhythm: rhythm})
+		for i, slot := range gen.schedule(commits) {
+			if slot.duration == 0 {
+				t.Errorf("rhythm %d, commit %d: zero-length note", rhythm, i)
+			}
+		}
+
+		writer, err := gen.Generate(commits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkNotesReleased(t, writer.Tracks()[0])
+	}
+}
+
+// scriptedMapper plays commit i at pitch 60+i, velocity 100-i and on
+// channel base+i, with notes of 240 ticks.
+type scriptedMapper struct{}
+
+func (scriptedMapper) Pitch(commit git.Commit, index int) byte    { return byte(60 + index) }
+func (scriptedMapper) Velocity(commit git.Commit, index int) byte { return byte(100 - index) }
+func (scriptedMapper) Duration(commit git.Commit, index int, base uint32) uint32 {
+	return 240
+}
+func (scriptedMapper) Channel(commit git.Commit, index int, base byte) byte {
+	return base + byte(index)
+}
+
+// accentMapper adds a modulation controller change at every note.
+type accentMapper struct{ scriptedMapper }
+
+func (accentMapper) Events(commit git.Commit, index int, note Note) []midi.TimedEvent {
+	return []midi.TimedEvent{{Tick: note.Tick, Data: midi.ControlChange(note.Channel, midi.CCModulation, byte(index))}}
+}
+
+func TestCustomMapper(t *testing.T) {
+	commits := testCommits()
+
+	for _, mapper := range []Mapper{scriptedMapper{}, accentMapper{}} {
+		gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 120, Scale: ScaleMajor, Mapper: mapper})
+		writer, err := gen.Generate(commits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var notes, controls []midi.TimedEvent
+		for _, event := range writer.Tracks()[0].Timeline().Events() {
+			switch status := event.Data[0] & 0xF0; {
+			case status == 0x90 && event.Data[2] > 0:
+				notes = append(notes, event)
+			case status == 0xB0 && event.Data[1] == midi.CCModulation:
+				controls = append(controls, event)
+			}
+		}
+
+		if len(notes) != len(commits) {
+			t.Fatalf("%T: got %d notes, want %d", mapper, len(notes), len(commits))
+		}
+		for i, note := range notes {
+			want := midi.NoteOn(byte(i), byte(60+i), byte(100-i))
+			if string(note.Data) != string(want) || note.Tick != uint32(240*i) {
+				t.Errorf("%T note %d: got % x at %d, want % x at %d", mapper, i, note.Data, note.Tick, want, 240*i)
+			}
+		}
+
+		wantControls := 0
+		if _, ok := mapper.(EventMapper); ok {
+			wantControls = len(commits)
+		}
+		if len(controls) != wantControls {
+			t.Fatalf("%T: got %d extra events, want %d", mapper, len(controls), wantControls)
+		}
+		for i, control := range controls {
+			if control.Tick != notes[i].Tick || control.Data[0]&0x0F != byte(i) || control.Data[2] != byte(i) {
+				t.Errorf("%T extra event %d: got % x at %d", mapper, i, control.Data, control.Tick)
+			}
+		}
+	}
+}
+
+// baselinePitch is the pitch mapping git2midi had before mappers were
+// pluggable: a degree of C pentatonic minor in one of three octaves from C4,
+// clamped to C6.
+func baselinePitch(hash string) byte {
+	h := fnv.New32a()
+	h.Write([]byte(hash))
+	hashValue := h.Sum32()
+
+	pentatonic := []byte{0, 3, 5, 7, 10}
+	scaleDegree := hashValue % uint32(len(pentatonic))
+	octaveOffset := (hashValue / uint32(len(pentatonic))) % 3
+
+	note := 60 + int(pentatonic[scaleDegree]) + int(octaveOffset)*12
+	if note > 84 {
+		note = 84
+	}
+	return byte(note)
+}
+
+// TestDefaultMapper pins the built-in mapping to the behavior it had before
+// mappers were pluggable.
+func TestDefaultMapper(t *testing.T) {
+	m := DefaultMapper{Scale: ScalePentatonicMinor}
+
+	pitches := []struct {
+		hash string
+		want byte
+	}{
+		{hash: "a1b2c3d", want: 63},
+		{hash: "0000000", want: 84},
+		{hash: "ffffffffffffffffffffffffffffffffffffffff", want: 84},
+	}
+	for _, tt := range pitches {
+		commit := git.Commit{Hash: tt.hash}
+		if got := m.Pitch(commit, 0); got != tt.want || got != baselinePitch(tt.hash) {
+			t.Errorf("Pitch(%s) = %d, want %d", tt.hash, got, tt.want)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		hash := fmt.Sprintf("%040x", i*7919)
+		if got, want := m.Pitch(git.Commit{Hash: hash}, i), baselinePitch(hash); got != want {
+			t.Errorf("Pitch(%s) = %d, want %d", hash, got, want)
+		}
+	}
+
+	velocities := []struct {
+		message string
+		want    byte
+	}{
+		{message: "", want: 40},
+		{message: "Fix typo", want: 43},
+		{message: string(make([]byte, 300)), want: 127},
+	}
+	for _, tt := range velocities {
+		commit := git.Commit{Message: tt.message}
+		if got := m.Velocity(commit, 0); got != tt.want || got != messageToVelocity(tt.message) {
+			t.Errorf("Velocity(%d-byte message) = %d, want %d", len(tt.message), got, tt.want)
+		}
+	}
+
+	durations := []uint32{120, 120, 120, 120, 90, 120, 120, 120, 90, 120}
+	for i, want := range durations {
+		if got := m.Duration(git.Commit{}, i, 120); got != want || got != calculateRhythm(i, 120) {
+			t.Errorf("Duration(%d) = %d, want %d", i, got, want)
+		}
+	}
+
+	if got := m.Channel(git.Commit{}, 3, 5); got != 5 {
+		t.Errorf("Channel = %d, want the track's channel", got)
+	}
+}
+
+func TestConfiguredMapperPitch(t *testing.T) {
+	hashes := []string{"a1b2c3d", "0000000", "ffffffffffffffffffffffffffffffffffffffff"}
+
+	tests := []struct {
+		name   string
+		mapper DefaultMapper
+		legacy bool
+	}{
+		{name: "zero value", mapper: DefaultMapper{}, legacy: true},
+		{name: "default range", mapper: DefaultMapper{Scale: ScalePentatonicMinor, Low: DefaultLowNote, High: DefaultHighNote}, legacy: true},
+		{name: "other scale", mapper: DefaultMapper{Scale: ScaleMajor}},
+		{name: "other key", mapper: DefaultMapper{Scale: ScalePentatonicMinor, Root: 2}},
+		{name: "other range", mapper: DefaultMapper{Scale: ScalePentatonicMinor, Low: 48, High: 72}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := tt.mapper.notes()
+			for _, hash := range hashes {
+				want := hashToPitch(hash, notes)
+				if tt.legacy {
+					want = defaultHashToPitch(hash)
+				}
+				if got := tt.mapper.Pitch(git.Commit{Hash: hash}, 0); got != want {
+					t.Errorf("Pitch(%s) = %d, want %d", hash, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package music
+
+import (
+	"strings"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+// Accent selects the musical gesture played at tagged commits.
+type Accent int
+
+const (
+	// AccentNone only marks tagged commits.
+	AccentNone Accent = iota
+	// AccentCadence plays a dominant-to-tonic cadence resolving on the
+	// tagged commit.
+	AccentCadence
+	// AccentCymbal plays a crash cymbal on the drum channel.
+	AccentCymbal
+)
+
+// markerText returns the Marker text for a commit: its tags and, if enabled,
+// its branch heads. It is empty for commits that are not marked.
+func (g *Generator) markerText(commit git.Commit) string {
+	refs := commit.Tags
+	if g.config.MarkBranches {
+		refs = append(append([]string(nil), refs...), commit.Branches...)
+	}
+	return strings.Join(refs, ", ")
+}
+
+// addMarkers adds a Marker event at every tagged commit, so a DAW can
+// navigate the song by release.
+func (g *Generator) addMarkers(timeline *midi.Timeline, commits []git.Commit, schedule []scheduledNote) {
+	for i, commit := range commits {
+		g.addMarker(timeline, commit, schedule[i])
+	}
+}
+
+// addMarker adds a Marker event at a commit's note if the commit is marked.
+func (g *Generator) addMarker(timeline *midi.Timeline, commit git.Commit, slot scheduledNote) {
+	if text := g.markerText(commit); text != "" {
+		timeline.Add(slot.tick, midi.Marker(text))
+	}
+}
+
+// addAccent plays the configured accent for a tagged commit's note, in the
+// key on root.
+func (g *Generator) addAccent(timeline *midi.Timeline, note Note, root int) {
+	switch g.config.TagAccent {
+	case AccentCymbal:
+		timeline.AddNote(note.Tick, uint32(g.config.Ticks), drumChannel, crashCymbal, note.Velocity)
+	case AccentCadence:
+		g.addCadence(timeline, note, root)
+	}
+}
+
+// addCadence plays the dominant triad one beat before the note and the tonic
+// triad, major or minor to match the scale, for two beats from the note.
+func (g *Generator) addCadence(timeline *midi.Timeline, note Note, root int) {
+	beat := uint32(g.config.Ticks)
+
+	// Voice the chords on the root at or below the bottom of the range.
+	tonic := pitchAbove(root, g.lowNote()-11)
+
+	third := 4
+	if _, minor := g.config.Scale.KeySignature(root); minor {
+		third = 3
+	}
+
+	if note.Tick >= beat {
+		for _, interval := range []int{-5, -1, 2} {
+			timeline.AddNote(note.Tick-beat, beat, note.Channel, chordTone(tonic+interval), note.Velocity)
+		}
+	}
+	for _, interval := range []int{0, third, 7} {
+		timeline.AddNote(note.Tick, 2*beat, note.Channel, chordTone(tonic+interval), note.Velocity)
+	}
+}
+
+// chordTone clamps a pitch to the MIDI note range.
+func chordTone(pitch int) byte {
+	if pitch < 0 {
+		return 0
+	}
+	if pitch > 127 {
+		return 127
+	}
+	return byte(pitch)
+}
@@ -0,0 +1,81 @@
+package music
+
+import (
+	"testing"
+
+	"github.com/klejdi94/git2midi/git"
+	"github.com/klejdi94/git2midi/midi"
+)
+
+func TestTagMarkers(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Author: "Alice", Message: "Initial commit", Branches: []string{"release"}},
+		{Hash: "b2", Author: "Bob", Message: "Release", Tags: []string{"v1.0", "v1.0.0"}, Branches: []string{"main"}},
+	}
+
+	tests := []struct {
+		name     string
+		branches bool
+		want     []string
+	}{
+		{name: "tags", want: []string{"v1.0, v1.0.0"}},
+		{name: "branches", branches: true, want: []string{"release", "v1.0, v1.0.0, main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 480, Scale: ScaleMajor, MarkBranches: tt.branches})
+			writer, err := gen.Generate(commits)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := metaTexts(writer.Tracks()[0], midi.MetaMarker)
+			if len(got) != len(tt.want) {
+				t.Fatalf("markers: got %q, want %q", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("marker %d: got %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTagAccent(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Author: "Alice", Message: "Initial commit"},
+		{Hash: "b2", Author: "Bob", Message: "Release", Tags: []string{"v1.0"}},
+	}
+
+	tests := []struct {
+		name   string
+		accent Accent
+		notes  int
+	}{
+		{name: "none", accent: AccentNone, notes: 2},
+		{name: "cymbal", accent: AccentCymbal, notes: 3},
+		{name: "cadence", accent: AccentCadence, notes: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&Config{BPM: 120, Ticks: 480, Duration: 480, Scale: ScaleMajor, TagAccent: tt.accent})
+			writer, err := gen.Generate(commits)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			notes := 0
+			for _, event := range writer.Tracks()[0].Events() {
+				if event.Data[0]&0xF0 == 0x90 && event.Data[2] > 0 {
+					notes++
+				}
+			}
+			if notes != tt.notes {
+				t.Errorf("note-ons: got %d, want %d", notes, tt.notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package music
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Scale is a set of intervals, in semitones above the root, that notes are
+// drawn from.
+type Scale struct {
+	Name      string
+	Intervals []int
+}
+
+var (
+	// ScaleMajor is the major (Ionian) scale.
+	ScaleMajor = Scale{Name: "major", Intervals: []int{0, 2, 4, 5, 7, 9, 11}}
+	// ScaleNaturalMinor is the natural minor (Aeolian) scale.
+	ScaleNaturalMinor = Scale{Name: "minor", Intervals: []int{0, 2, 3, 5, 7, 8, 10}}
+	// ScaleHarmonicMinor is the harmonic minor scale.
+	ScaleHarmonicMinor = Scale{Name: "harmonic-minor", Intervals: []int{0, 2, 3, 5, 7, 8, 11}}
+	// ScaleMelodicMinor is the ascending melodic minor scale.
+	ScaleMelodicMinor = Scale{Name: "melodic-minor", Intervals: []int{0, 2, 3, 5, 7, 9, 11}}
+	// ScaleDorian is the Dorian mode.
+	ScaleDorian = Scale{Name: "dorian", Intervals: []int{0, 2, 3, 5, 7, 9, 10}}
+	// ScalePhrygian is the Phrygian mode.
+	ScalePhrygian = Scale{Name: "phrygian", Intervals: []int{0, 1, 3, 5, 7, 8, 10}}
+	// ScaleLydian is the Lydian mode.
+	ScaleLydian = Scale{Name: "lydian", Intervals: []int{0, 2, 4, 6, 7, 9, 11}}
+	// ScaleMixolydian is the Mixolydian mode.
+	ScaleMixolydian = Scale{Name: "mixolydian", Intervals: []int{0, 2, 4, 5, 7, 9, 10}}
+	// ScaleLocrian is the Locrian mode.
+	ScaleLocrian = Scale{Name: "locrian", Intervals: []int{0, 1, 3, 5, 6, 8, 10}}
+	// ScalePentatonicMajor is the major pentatonic scale.
+	ScalePentatonicMajor = Scale{Name: "pentatonic-major", Intervals: []int{0, 2, 4, 7, 9}}
+	// ScalePentatonicMinor is the minor pentatonic scale, the default.
+	ScalePentatonicMinor = Scale{Name: "pentatonic-minor", Intervals: []int{0, 3, 5, 7, 10}}
+	// ScaleBlues is the minor blues scale.
+	ScaleBlues = Scale{Name: "blues", Intervals: []int{0, 3, 5, 6, 7, 10}}
+	// ScaleWholeTone is the whole-tone scale.
+	ScaleWholeTone = Scale{Name: "whole-tone", Intervals: []int{0, 2, 4, 6, 8, 10}}
+	// ScaleChromatic contains all twelve semitones.
+	ScaleChromatic = Scale{Name: "chromatic", Intervals: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}
+)
+
+// scales maps scale names and their aliases to scales.
+var scales = map[string]Scale{
+	"major":            ScaleMajor,
+	"ionian":           ScaleMajor,
+	"minor":            ScaleNaturalMinor,
+	"natural-minor":    ScaleNaturalMinor,
+	"aeolian":          ScaleNaturalMinor,
+	"harmonic-minor":   ScaleHarmonicMinor,
+	"melodic-minor":    ScaleMelodicMinor,
+	"dorian":           ScaleDorian,
+	"phrygian":         ScalePhrygian,
+	"lydian":           ScaleLydian,
+	"mixolydian":       ScaleMixolydian,
+	"locrian":          ScaleLocrian,
+	"pentatonic-major": ScalePentatonicMajor,
+	"pentatonic-minor": ScalePentatonicMinor,
+	"pentatonic":       ScalePentatonicMinor,
+	"blues":            ScaleBlues,
+	"whole-tone":       ScaleWholeTone,
+	"chromatic":        ScaleChromatic,
+}
+
+const (
+	// DefaultRoot is the default root pitch class (C).
+	DefaultRoot = 0
+	// DefaultLowNote is the default lowest generated note (C4).
+	DefaultLowNote = 60
+	// DefaultHighNote is the default highest generated note (C6).
+	DefaultHighNote = 84
+)
+
+// ScaleNames returns the names of all built-in scales, including aliases.
+func ScaleNames() []string {
+	names := make([]string, 0, len(scales))
+	for name := range scales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseScale parses a built-in scale name or a comma-separated list of
+// semitone intervals such as "0,2,3,7,9".
+func ParseScale(s string) (Scale, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if scale, ok := scales[name]; ok {
+		return scale, nil
+	}
+
+	if !strings.Contains(name, ",") && !isNumber(name) {
+		return Scale{}, fmt.Errorf("%w: unknown scale %q", ErrInvalidScale, s)
+	}
+
+	seen := make(map[int]bool)
+	intervals := make([]int, 0)
+	for _, field := range strings.Split(name, ",") {
+		interval, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || interval < 0 || interval > 11 {
+			return Scale{}, fmt.Errorf("%w: interval %q must be between 0 and 11", ErrInvalidScale, field)
+		}
+		if !seen[interval] {
+			seen[interval] = true
+			intervals = append(intervals, interval)
+		}
+	}
+	sort.Ints(intervals)
+
+	return Scale{Name: "custom", Intervals: intervals}, nil
+}
+
+// Notes returns every note of the scale built on root (a pitch class, 0-11)
+// that lies within [low, high], in ascending order.
+func (s Scale) Notes(root, low, high int) []byte {
+	intervals := s.Intervals
+	if len(intervals) == 0 {
+		intervals = ScalePentatonicMinor.Intervals
+	}
+
+	inScale := make(map[int]bool, len(intervals))
+	for _, interval := range intervals {
+		inScale[((root+interval)%12+12)%12] = true
+	}
+
+	if low < 0 {
+		low = 0
+	}
+	if high > 127 {
+		high = 127
+	}
+
+	notes := make([]byte, 0)
+	for note := low; note <= high; note++ {
+		if inScale[note%12] {
+			notes = append(notes, byte(note))
+		}
+	}
+	return notes
+}
+
+// majorKeySharps maps the pitch class of a major key to the number of sharps
+// (positive) or flats (negative) in its key signature.
+var majorKeySharps = [12]int{0, -5, 2, -3, 4, -1, 6, 1, -4, 3, -2, 5}
+
+// KeySignature returns the key signature of the scale built on root, as the
+// number of sharps (positive) or flats (negative) and whether it is minor.
+// Modes of the major scale use the signature of their parent major key;
+// other scales are treated as major or minor by their third.
+func (s Scale) KeySignature(root int) (int, bool) {
+	intervals := s.Intervals
+	if len(intervals) == 0 {
+		intervals = ScalePentatonicMinor.Intervals
+	}
+
+	has := make(map[int]bool, len(intervals))
+	for _, interval := range intervals {
+		has[interval] = true
+	}
+	minor := has[3] && !has[4]
+
+	// Find the degree of the major scale this scale is a mode of.
+	for _, degree := range ScaleMajor.Intervals {
+		if len(intervals) != len(ScaleMajor.Intervals) {
+			break
+		}
+		mode := true
+		for _, interval := range ScaleMajor.Intervals {
+			if !has[((interval-degree)%12+12)%12] {
+				mode = false
+				break
+			}
+		}
+		if mode {
+			return majorKeySharps[((root-degree)%12+12)%12], minor
+		}
+	}
+
+	if minor {
+		// A minor key shares its signature with its relative major.
+		return majorKeySharps[(root+3)%12], true
+	}
+	return majorKeySharps[root%12], false
+}
+
+// noteNames maps note letters to pitch classes.
+var noteNames = map[byte]int{'c': 0, 'd': 2, 'e': 4, 'f': 5, 'g': 7, 'a': 9, 'b': 11}
+
+// ParseKey parses a key name such as "C", "F#" or "Bb" into a pitch class (0-11).
+func ParseKey(s string) (int, error) {
+	pitchClass, rest, err := parsePitchClass(s)
+	if err != nil || rest != "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidKey, s)
+	}
+	return (pitchClass + 12) % 12, nil
+}
+
+// ParseNote parses a MIDI note number ("60") or a note name with octave
+// ("C4", "F#3", "Bb5"), where C4 is middle C (60).
+func ParseNote(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if isNumber(s) {
+		note, err := strconv.Atoi(s)
+		if err != nil || note > 127 {
+			return 0, fmt.Errorf("%w: note %q out of range", ErrInvalidRange, s)
+		}
+		return note, nil
+	}
+
+	pitchClass, rest, err := parsePitchClass(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: note %q", ErrInvalidRange, s)
+	}
+	octave, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0, fmt.Errorf("%w: note %q has no octave", ErrInvalidRange, s)
+	}
+
+	note := (octave+1)*12 + pitchClass
+	if note < 0 || note > 127 {
+		return 0, fmt.Errorf("%w: note %q out of range", ErrInvalidRange, s)
+	}
+	return note, nil
+}
+
+// ParseRange parses a pitch range such as "60-84" or "C4-C6". Notes in
+// octave -1 can be written with either separator, as in "C-1-C4" or
+// "C-1..C4".
+func ParseRange(s string) (low, high int, err error) {
+	lowNote, highNote, ok := splitRange(s)
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %q (expected LOW-HIGH)", ErrInvalidRange, s)
+	}
+
+	if low, err = ParseNote(lowNote); err != nil {
+		return 0, 0, err
+	}
+	if high, err = ParseNote(highNote); err != nil {
+		return 0, 0, err
+	}
+	if low >= high {
+		return 0, 0, fmt.Errorf("%w: %q (low note must be below high note)", ErrInvalidRange, s)
+	}
+	return low, high, nil
+}
+
+// splitRange splits a range at "..", or else at the first "-" that ends a
+// complete low note, so that the "-" of a negative octave stays with its
+// note.
+func splitRange(s string) (string, string, bool) {
+	if low, high, ok := strings.Cut(s, ".."); ok {
+		return low, high, true
+	}
+
+	first := strings.Index(s, "-")
+	if first < 0 {
+		return "", "", false
+	}
+	for i := first; i < len(s); i++ {
+		if s[i] != '-' {
+			continue
+		}
+		if _, err := ParseNote(s[:i]); err == nil {
+			return s[:i], s[i+1:], true
+		}
+	}
+	// No complete low note: report the note before the first "-".
+	return s[:first], s[first+1:], true
+}
+
+// parsePitchClass parses a note letter with optional accidentals and returns
+// the semitone offset from C, which can fall outside 0-11 for notes such as
+// "Cb" or "B#", and the unparsed remainder.
+func parsePitchClass(s string) (int, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, "", fmt.Errorf("empty note name")
+	}
+
+	pitchClass, ok := noteNames[strings.ToLower(s[:1])[0]]
+	if !ok {
+		return 0, "", fmt.Errorf("unknown note %q", s[:1])
+	}
+
+	rest := s[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '#':
+			pitchClass++
+		case 'b':
+			pitchClass--
+		default:
+			return pitchClass, rest, nil
+		}
+		rest = rest[1:]
+	}
+	return pitchClass, rest, nil
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package music
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseScale(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		intervals []int
+		err       error
+	}{
+		{name: "built-in", input: "dorian", intervals: []int{0, 2, 3, 5, 7, 9, 10}},
+		{name: "alias", input: "Aeolian", intervals: []int{0, 2, 3, 5, 7, 8, 10}},
+		{name: "custom", input: "7, 0,3,3", intervals: []int{0, 3, 7}},
+		{name: "unknown", input: "klingon", err: ErrInvalidScale},
+		{name: "out of range", input: "0,12", err: ErrInvalidScale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale, err := ParseScale(tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error: got %v, want %v", err, tt.err)
+			}
+			if len(scale.Intervals) != len(tt.intervals) {
+				t.Fatalf("intervals: got %v, want %v", scale.Intervals, tt.intervals)
+			}
+			for i := range tt.intervals {
+				if scale.Intervals[i] != tt.intervals[i] {
+					t.Errorf("intervals: got %v, want %v", scale.Intervals, tt.intervals)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		input string
+		low   int
+		high  int
+		err   error
+	}{
+		{input: "60-84", low: 60, high: 84},
+		{input: "C4-C6", low: 60, high: 84},
+		{input: "F#3-Bb4", low: 54, high: 70},
+		{input: "B#3-C5", low: 60, high: 72},
+		{input: "C6-C4", err: ErrInvalidRange},
+		{input: "C4", err: ErrInvalidRange},
+		{input: "H2-C4", err: ErrInvalidRange},
+		{input: "0-200", err: ErrInvalidRange},
+		{input: "C-1-C4", low: 0, high: 60},
+		{input: "C-1..C4", low: 0, high: 60},
+		{input: "G#-1-127", low: 8, high: 127},
+		{input: "48..72", low: 48, high: 72},
+		{input: "C4-C-1", err: ErrInvalidRange},
+		{input: "C-2-C4", err: ErrInvalidRange},
+		{input: "C4..", err: ErrInvalidRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			low, high, err := ParseRange(tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error: got %v, want %v", err, tt.err)
+			}
+			if low != tt.low || high != tt.high {
+				t.Errorf("range: got %d-%d, want %d-%d", low, high, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestScaleNotes(t *testing.T) {
+	root, err := ParseKey("D")
+	if err != nil {
+		t.Fatalf("ParseKey failed: %v", err)
+	}
+
+	notes := ScaleMajor.Notes(root, 60, 72)
+	expected := []byte{61, 62, 64, 66, 67, 69, 71}
+	if len(notes) != len(expected) {
+		t.Fatalf("notes: got %v, want %v", notes, expected)
+	}
+	for i := range expected {
+		if notes[i] != expected[i] {
+			t.Errorf("notes: got %v, want %v", notes, expected)
+		}
+	}
+}
+
+func TestKeySignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		scale  Scale
+		root   int
+		sharps int
+		minor  bool
+	}{
+		{name: "C major", scale: ScaleMajor, root: 0, sharps: 0},
+		{name: "E major", scale: ScaleMajor, root: 4, sharps: 4},
+		{name: "A minor", scale: ScaleNaturalMinor, root: 9, sharps: 0, minor: true},
+		{name: "D dorian", scale: ScaleDorian, root: 2, sharps: 0, minor: true},
+		{name: "F lydian", scale: ScaleLydian, root: 5, sharps: 0},
+		{name: "C pentatonic minor", scale: ScalePentatonicMinor, root: 0, sharps: -3, minor: true},
+		{name: "G pentatonic major", scale: ScalePentatonicMajor, root: 7, sharps: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sharps, minor := tt.scale.KeySignature(tt.root)
+			if sharps != tt.sharps || minor != tt.minor {
+				t.Errorf("got (%d, %v), want (%d, %v)", sharps, minor, tt.sharps, tt.minor)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package music
+
+import (
+	"math"
+	"time"
+
+	"github.com/klejdi94/git2midi/git"
+)
+
+// Rhythm selects how commits are spaced in time.
+type Rhythm int
+
+const (
+	// RhythmFixed spaces commits with the built-in rhythm pattern.
+	RhythmFixed Rhythm = iota
+	// RhythmTimestamp spaces commits by the wall-clock time between them.
+	RhythmTimestamp
+)
+
+// Compression selects how wall-clock gaps are mapped to musical time.
+type Compression int
+
+const (
+	// CompressionLinear maps gaps proportionally.
+	CompressionLinear Compression = iota
+	// CompressionLog maps gaps logarithmically, shrinking long pauses the most.
+	CompressionLog
+	// CompressionQuantized maps gaps proportionally and snaps them to a grid.
+	CompressionQuantized
+)
+
+// TimeMapping configures how commit timestamps drive the rhythm.
+type TimeMapping struct {
+	// Compression selects the gap mapping curve.
+	Compression Compression
+	// BeatDuration is the wall-clock gap that maps to one quarter note.
+	BeatDuration time.Duration
+	// Grid is the quantization step in ticks (0 = a sixteenth note).
+	Grid uint32
+	// MaxSilence caps any single gap in ticks (0 = no cap).
+	MaxSilence uint32
+}
+
+// DefaultBeatDuration is the wall-clock gap mapped to one beat when none is configured.
+const DefaultBeatDuration = time.Hour
+
+// gapToTicks converts the wall-clock gap between two commits into ticks.
+func (m *TimeMapping) gapToTicks(seconds int64, ticksPerBeat uint32) uint32 {
+	if seconds <= 0 {
+		return 0
+	}
+
+	beat := m.BeatDuration
+	if beat <= 0 {
+		beat = DefaultBeatDuration
+	}
+	beats := float64(seconds) / beat.Seconds()
+
+	var ticks float64
+	switch m.Compression {
+	case CompressionLog:
+		ticks = math.Log2(1+beats) * float64(ticksPerBeat)
+	case CompressionQuantized:
+		grid := m.Grid
+		if grid == 0 {
+			grid = ticksPerBeat / 4
+		}
+		if grid == 0 {
+			grid = 1
+		}
+		ticks = math.Round(beats*float64(ticksPerBeat)/float64(grid)) * float64(grid)
+	default:
+		ticks = beats * float64(ticksPerBeat)
+	}
+
+	if m.MaxSilence > 0 && ticks > float64(m.MaxSilence) {
+		return m.MaxSilence
+	}
+	if ticks > math.MaxUint32/2 {
+		return math.MaxUint32 / 2
+	}
+	return uint32(ticks)
+}
+
+// timestampSchedule places commits according to the real time between them.
+// Commits that happened close together sound as bursts or chords, and quiet
+// periods become rests.
+func (g *Generator) timestampSchedule(commits []git.Commit) []scheduledNote {
+	notes := make([]scheduledNote, len(commits))
+	ticksPerBeat := uint32(g.config.Ticks)
+
+	tick := uint32(0)
+	for i, commit := range commits {
+		if i > 0 {
+			gap := commit.Timestamp - commits[i-1].Timestamp
+			tick += g.config.Time.gapToTicks(gap, ticksPerBeat)
+		}
+		duration := g.duration(commit, i)
+		notes[i] = scheduledNote{tick: tick, duration: duration}
+	}
+
+	return notes
+}
@@ -0,0 +1,70 @@
+package music
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/klejdi94/git2midi/git"
+)
+
+func TestGapToTicks(t *testing.T) {
+	const ticksPerBeat = 480
+	hour := int64(time.Hour / time.Second)
+
+	tests := []struct {
+		name    string
+		mapping TimeMapping
+		seconds int64
+		want    uint32
+	}{
+		{name: "equal timestamps", mapping: TimeMapping{BeatDuration: time.Hour}, seconds: 0, want: 0},
+		{name: "out of order", mapping: TimeMapping{BeatDuration: time.Hour}, seconds: -hour, want: 0},
+		{name: "linear beat", mapping: TimeMapping{BeatDuration: time.Hour}, seconds: hour, want: 480},
+		{name: "linear half beat", mapping: TimeMapping{BeatDuration: time.Hour}, seconds: hour / 2, want: 240},
+		{name: "default beat", mapping: TimeMapping{}, seconds: 2 * hour, want: 960},
+		{name: "log one beat", mapping: TimeMapping{Compression: CompressionLog, BeatDuration: time.Hour}, seconds: hour, want: 480},
+		{name: "log three beats", mapping: TimeMapping{Compression: CompressionLog, BeatDuration: time.Hour}, seconds: 3 * hour, want: 960},
+		{name: "quantize sixteenth", mapping: TimeMapping{Compression: CompressionQuantized, BeatDuration: time.Hour}, seconds: 50 * 60, want: 360},
+		{name: "quantize beat grid", mapping: TimeMapping{Compression: CompressionQuantized, BeatDuration: time.Hour, Grid: 480}, seconds: 50 * 60, want: 480},
+		{name: "quantize below half grid", mapping: TimeMapping{Compression: CompressionQuantized, BeatDuration: time.Hour, Grid: 480}, seconds: 20 * 60, want: 0},
+		{name: "max silence", mapping: TimeMapping{BeatDuration: time.Hour, MaxSilence: 960}, seconds: 10 * hour, want: 960},
+		{name: "below max silence", mapping: TimeMapping{BeatDuration: time.Hour, MaxSilence: 960}, seconds: hour, want: 480},
+		{name: "overflow", mapping: TimeMapping{BeatDuration: time.Second}, seconds: 1 << 50, want: math.MaxUint32 / 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mapping.gapToTicks(tt.seconds, ticksPerBeat); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimestampSchedule(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Message: "First", Timestamp: 1700000000},
+		{Hash: "b2", Message: "An hour later", Timestamp: 1700003600},
+		{Hash: "c3", Message: "Committed with a clock behind", Timestamp: 1700001800},
+		{Hash: "d4", Message: "An hour after that", Timestamp: 1700005400},
+		{Hash: "e5", Message: "Same second", Timestamp: 1700005400},
+	}
+
+	gen := NewGenerator(&Config{
+		BPM: 120, Ticks: 480, Duration: 120, Scale: ScaleMajor,
+		Rhythm: RhythmTimestamp,
+		Time:   TimeMapping{Compression: CompressionLinear, BeatDuration: time.Hour},
+	})
+	schedule := gen.schedule(commits)
+
+	want := []uint32{0, 480, 480, 960, 960}
+	for i, slot := range schedule {
+		if slot.tick != want[i] {
+			t.Errorf("commit %d: got tick %d, want %d", i, slot.tick, want[i])
+		}
+		if want := calculateRhythm(i, 120); slot.duration != want {
+			t.Errorf("commit %d: got duration %d, want %d", i, slot.duration, want)
+		}
+	}
+}